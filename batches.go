@@ -0,0 +1,27 @@
+package rip
+
+import "io"
+
+// ReadBatches is like Read, but passes the callback batches of up to BatchSize
+// chunks at a time rather than one, for consumers with a high fixed cost per
+// call, like a bulk database insert. Chunks in a batch are in stream order, but
+// batches are assembled by each worker from the chunks it receives, so a batch
+// isn't necessarily a contiguous run of the stream, and up to Concurrency
+// batches at the end of the stream may be smaller than BatchSize. A BatchSize
+// of 0 is treated as 1.
+//
+// The chunks, and the batch slice itself, must not be used after the callback
+// returns.
+func (r *ParallelReader) ReadBatches(stream io.Reader, work func(batch [][]byte)) Result {
+	err := r.dispatchBatches(r.producer(stream), stream, nil, max(r.BatchSize, 1), func(batch []*chunk) {
+		chunks := make([][]byte, len(batch))
+		for i, c := range batch {
+			chunks[i] = c.ReadableBytes()
+			if r.NormalizeLineEndings {
+				chunks[i] = chunks[i][:normalizeLineEndings(chunks[i])]
+			}
+		}
+		work(chunks)
+	})
+	return r.finish(err)
+}
@@ -0,0 +1,55 @@
+package rip
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBatches(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("with BatchSize", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 1
+		r.ChunkSize = 4
+		r.BatchSize = 2
+
+		var batches [][]string
+		r.ReadBatches(strings.NewReader("abc\ndef\nghi\n"), func(batch [][]byte) {
+			var chunks []string
+			for _, chunk := range batch {
+				chunks = append(chunks, string(chunk))
+			}
+			batches = append(batches, chunks)
+		})
+
+		assert.Equal([][]string{{"abc\n", "def\n"}, {"ghi\n"}}, batches)
+	})
+
+	t.Run("returns every buffer to the pool", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.BatchSize = 3
+
+		var borrowed, returned int64
+		pool := r.Pool()
+		pool.OnBorrow = func(buf []byte) { atomic.AddInt64(&borrowed, 1) }
+		pool.OnReturn = func(buf []byte) { atomic.AddInt64(&returned, 1) }
+
+		chunks := make(chan string, 128)
+		r.ReadBatches(strings.NewReader(strings.Repeat("abc\n", 100)), func(batch [][]byte) {
+			assert.LessOrEqual(len(batch), 3)
+			for _, chunk := range batch {
+				chunks <- string(chunk)
+			}
+		})
+		close(chunks)
+
+		assert.Len(drain(chunks), 100)
+		assert.EqualValues(100, borrowed)
+		assert.Equal(borrowed, returned)
+	})
+}
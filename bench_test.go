@@ -0,0 +1,155 @@
+package rip
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// BenchmarkPoolContention borrows and returns 64 KiB buffers from 32
+// goroutines per CPU at once, as workers returning buffers while the scanner
+// borrows them would, to compare the cost of the shared pool against copying
+// a chunk into the buffer, which the scanner does for every chunk anyway.
+func BenchmarkPoolContention(b *testing.B) {
+	const bufferSize = 64 << 10
+	chunk := make([]byte, bufferSize)
+
+	b.Run("Pool", func(b *testing.B) {
+		pool := NewPool(32, bufferSize)
+		pool.Fill()
+
+		b.SetParallelism(32)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				pool.Return(pool.Borrow())
+			}
+		})
+	})
+
+	b.Run("Copy", func(b *testing.B) {
+		b.SetParallelism(32)
+		b.RunParallel(func(pb *testing.PB) {
+			buf := make([]byte, bufferSize)
+			for pb.Next() {
+				copy(buf, chunk)
+			}
+		})
+	})
+}
+
+// stallingReader is a source that stalls on every 16th read, like a network
+// stream that's occasionally slow to deliver.
+type stallingReader struct {
+	remaining int
+	reads     int
+}
+
+func (s *stallingReader) Read(p []byte) (int, error) {
+	if s.remaining == 0 {
+		return 0, io.EOF
+	}
+	s.reads++
+	if s.reads%16 == 0 {
+		time.Sleep(4 * time.Millisecond)
+	}
+	n := min(len(p), s.remaining)
+	s.remaining -= n
+	return n, nil
+}
+
+func BenchmarkReadFixedPrefetch(b *testing.B) {
+	const size = 256 << 10
+
+	for _, prefetch := range []int{0, 16} {
+		b.Run(fmt.Sprintf("Prefetch=%d", prefetch), func(b *testing.B) {
+			r := NewParallelReader()
+			r.Concurrency = 4
+			r.ChunkSize = 1 << 10
+			r.Prefetch = prefetch
+			r.Warm()
+
+			b.SetBytes(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.ReadFixed(&stallingReader{remaining: size}, func(chunk []byte) {
+					time.Sleep(200 * time.Microsecond)
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkReadNoCopy(b *testing.B) {
+	input := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog\n"), 1<<16)
+
+	for _, noCopy := range []bool{false, true} {
+		b.Run(fmt.Sprintf("NoCopy=%t", noCopy), func(b *testing.B) {
+			r := NewParallelReader()
+			r.Concurrency = 4
+			r.NoCopy = noCopy
+			// A pool of the same size either way, so that only copying differs.
+			r.PoolSize = 16
+
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			r.Warm()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.Read(bytes.NewReader(input), func(chunk []byte) {})
+			}
+		})
+	}
+}
+
+func BenchmarkReadSmall(b *testing.B) {
+	input := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog\n"), 16)
+
+	for _, minParallelSize := range []int{0, 64 << 10} {
+		b.Run(fmt.Sprintf("MinParallelSize=%d", minParallelSize), func(b *testing.B) {
+			r := NewParallelReader()
+			r.Concurrency = 4
+			r.MinParallelSize = minParallelSize
+			r.Warm()
+
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.Read(bytes.NewReader(input), func(chunk []byte) {})
+			}
+		})
+	}
+}
+
+// BenchmarkTransformUnevenCost transforms chunks of which one in 64 takes
+// 20 times as long as the rest, so the reorder window fills up behind the slow
+// ones. A larger window lets workers get further ahead in the meantime.
+func BenchmarkTransformUnevenCost(b *testing.B) {
+	input := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog\n"), 1<<10)
+
+	for _, window := range []int{0, 64} {
+		b.Run(fmt.Sprintf("MaxReorderBuffer=%d", window), func(b *testing.B) {
+			r := NewParallelReader()
+			r.Concurrency = 4
+			r.ChunkSize = len(input) / 256
+			r.MaxReorderBuffer = window
+
+			b.SetBytes(int64(len(input)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var chunks int64
+				r.Transform(bytes.NewReader(input), io.Discard, func(chunk []byte) []byte {
+					if atomic.AddInt64(&chunks, 1)%64 == 0 {
+						time.Sleep(20 * time.Millisecond)
+					} else {
+						time.Sleep(time.Millisecond)
+					}
+					return chunk
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package rip
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// ErrBinaryInput is returned when RejectBinary is set and the start of the
+// stream looks like binary data rather than text.
+var ErrBinaryInput = errors.New("rip: stream looks like binary data")
+
+// The number of bytes RejectBinary samples from the start of a stream.
+const binarySampleSize = 8 << 10 // 8 KiB
+
+// The fraction of a sample's bytes that have to be control characters for
+// RejectBinary to take it for binary data.
+const binaryControlFraction = 0.3
+
+// rejectingBinary wraps stream to fail with ErrBinaryInput, before any of it
+// is read, if RejectBinary is set and it looks binary.
+func (r *ParallelReader) rejectingBinary(stream io.Reader) io.Reader {
+	if !r.RejectBinary {
+		return stream
+	}
+	return &binaryGuard{r: r, src: bufio.NewReaderSize(stream, binarySampleSize)}
+}
+
+// binaryGuard is an io.Reader that checks the start of src with looksBinary
+// on the first read.
+type binaryGuard struct {
+	r       *ParallelReader
+	src     *bufio.Reader
+	checked bool
+	err     error
+}
+
+func (g *binaryGuard) Read(p []byte) (int, error) {
+	if !g.checked {
+		g.checked = true
+		if sample, _ := g.src.Peek(binarySampleSize); g.r.looksBinary(sample) {
+			g.err = ErrBinaryInput
+		}
+	}
+	if g.err != nil {
+		return 0, g.err
+	}
+	return g.src.Read(p)
+}
+
+// looksBinary reports whether sample, from the start of a stream, looks like
+// binary data by the heuristic described for RejectBinary.
+func (r *ParallelReader) looksBinary(sample []byte) bool {
+	var separator [256]bool
+	for _, boundary := range append([]string{r.ChunkBoundary}, r.ChunkBoundaries...) {
+		for i := 0; i < len(boundary); i++ {
+			separator[boundary[i]] = true
+		}
+	}
+
+	control := 0
+	for _, b := range sample {
+		if separator[b] {
+			continue
+		}
+		switch b {
+		case 0:
+			return true
+		case '\t', '\n', '\v', '\f', '\r', '\b', 0x1b:
+		default:
+			if b < 0x20 || b == 0x7f {
+				control++
+			}
+		}
+	}
+	return float64(control) > binaryControlFraction*float64(len(sample))
+}
@@ -0,0 +1,92 @@
+package rip
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRejectBinary(t *testing.T) {
+	assert := assert.New(t)
+
+	newReader := func() *ParallelReader {
+		r := NewParallelReader()
+		r.RejectBinary = true
+		r.RecoverPanics = true
+		return r
+	}
+	read := func(r *ParallelReader, input string) (chunks int, err error) {
+		result := r.ReadSerial(strings.NewReader(input), func(chunk []byte) { chunks++ })
+		return chunks, result.Err
+	}
+
+	t.Run("with a NUL byte", func(t *testing.T) {
+		r := newReader()
+
+		chunks, err := read(r, "ELF\x00\x01\x02\nabc\n")
+		assert.ErrorIs(err, ErrBinaryInput)
+		assert.Zero(chunks)
+	})
+
+	t.Run("with mostly control characters", func(t *testing.T) {
+		r := newReader()
+
+		_, err := read(r, strings.Repeat("\x01\x02\x03a\n", 100))
+		assert.ErrorIs(err, ErrBinaryInput)
+	})
+
+	t.Run("with text", func(t *testing.T) {
+		for name, input := range map[string]string{
+			"ASCII":          strings.Repeat("abc\tdef\r\n", 100),
+			"UTF-8":          strings.Repeat("héllo wörld ✓\n", 100),
+			"color codes":    strings.Repeat("\x1b[31merror\x1b[0m\n", 100),
+			"a few controls": strings.Repeat("form\x0cfeed\x07bell\n", 100),
+		} {
+			r := newReader()
+
+			chunks, err := read(r, input)
+			assert.NoError(err, name)
+			assert.Positive(chunks, name)
+		}
+	})
+
+	t.Run("when the boundary is a NUL byte", func(t *testing.T) {
+		r := newReader()
+		r.ChunkBoundary = "\x00"
+
+		_, err := read(r, "a.txt\x00b.txt\x00")
+		assert.NoError(err)
+	})
+
+	t.Run("only samples the start of the stream", func(t *testing.T) {
+		r := newReader()
+
+		_, err := read(r, strings.Repeat("abc\n", binarySampleSize)+"\x00")
+		assert.NoError(err)
+	})
+
+	t.Run("without RejectBinary", func(t *testing.T) {
+		r := newReader()
+		r.RejectBinary = false
+
+		_, err := read(r, "ELF\x00\x01\x02\n")
+		assert.NoError(err)
+	})
+
+	t.Run("with a file that's scanned in parallel", func(t *testing.T) {
+		r := newReader()
+		r.ParallelScan = true
+
+		path := filepath.Join(t.TempDir(), "a.out")
+		assert.NoError(os.WriteFile(path, bytes.Repeat([]byte{0x7f, 'E', 'L', 'F', 0, 0, '\n'}, 100), 0o644))
+
+		err := r.ReadFile(path, func(chunk []byte) {
+			t.Error("unexpected chunk")
+		})
+		assert.ErrorIs(err, ErrBinaryInput)
+	})
+}
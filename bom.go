@@ -0,0 +1,128 @@
+package rip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// stripBOM consumes a byte order mark at the start of stream if StripBOM is
+// set, transcoding UTF-16 input to UTF-8 if TranscodeUTF16 is also set.
+func (r *ParallelReader) stripBOM(stream io.Reader) io.Reader {
+	if !r.StripBOM {
+		return stream
+	}
+
+	br := bufio.NewReader(stream)
+	start, _ := br.Peek(len(utf8BOM))
+
+	bom, order := detectBOM(start)
+	if bom == nil {
+		return br
+	}
+
+	br.Discard(len(bom))
+	if r.checkpoints != nil {
+		r.checkpoints.dropped(int64(len(bom)))
+	}
+	if order != nil && r.TranscodeUTF16 {
+		return &utf16Reader{src: br, order: order}
+	}
+	return br
+}
+
+// detectBOM returns the byte order mark that start begins with, if any, and
+// the byte order it implies for UTF-16.
+func detectBOM(start []byte) ([]byte, binary.ByteOrder) {
+	switch {
+	case bytes.HasPrefix(start, utf8BOM):
+		return utf8BOM, nil
+	case bytes.HasPrefix(start, utf16LEBOM):
+		return utf16LEBOM, binary.LittleEndian
+	case bytes.HasPrefix(start, utf16BEBOM):
+		return utf16BEBOM, binary.BigEndian
+	}
+	return nil, nil
+}
+
+// bomAt returns the length of the byte order mark StripBOM would strip from a
+// stream that starts at offset in source, or 0 if it isn't set.
+func (r *ParallelReader) bomAt(source io.ReaderAt, offset int64) int64 {
+	if !r.StripBOM {
+		return 0
+	}
+	start := make([]byte, len(utf8BOM))
+	n, _ := source.ReadAt(start, offset)
+	bom, _ := detectBOM(start[:n])
+	return int64(len(bom))
+}
+
+// utf16Reader decodes a stream of UTF-16 in the given byte order, producing
+// UTF-8. Invalid surrogates and a trailing odd byte are replaced with
+// utf8.RuneError.
+type utf16Reader struct {
+	src     *bufio.Reader
+	order   binary.ByteOrder
+	pending []byte
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.pending) < len(p) {
+		r, err := u.readRune()
+		if err != nil {
+			if len(u.pending) == 0 {
+				return 0, err
+			}
+			break
+		}
+		u.pending = utf8.AppendRune(u.pending, r)
+	}
+
+	n := copy(p, u.pending)
+	u.pending = u.pending[:copy(u.pending, u.pending[n:])]
+	return n, nil
+}
+
+func (u *utf16Reader) readRune() (rune, error) {
+	unit, err := u.readUnit()
+	if err != nil {
+		return 0, err
+	}
+
+	r := rune(unit)
+	if !utf16.IsSurrogate(r) {
+		return r, nil
+	}
+
+	// Only consume the next unit if it completes a surrogate pair; otherwise it
+	// starts the next rune.
+	next, err := u.src.Peek(2)
+	if err != nil {
+		return utf8.RuneError, nil
+	}
+	if pair := utf16.DecodeRune(r, rune(u.order.Uint16(next))); pair != utf8.RuneError {
+		u.src.Discard(2)
+		return pair, nil
+	}
+	return utf8.RuneError, nil
+}
+
+func (u *utf16Reader) readUnit() (uint16, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(u.src, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return utf8.RuneError, nil
+		}
+		return 0, err
+	}
+	return u.order.Uint16(b[:]), nil
+}
@@ -0,0 +1,68 @@
+package rip
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripBOM(t *testing.T) {
+	assert := assert.New(t)
+
+	read := func(r *ParallelReader, input []byte) string {
+		var out bytes.Buffer
+		err := r.Transform(bytes.NewReader(input), &out, func(chunk []byte) []byte {
+			return chunk
+		})
+		assert.NoError(err)
+		return out.String()
+	}
+
+	encodeUTF16LE := func(s string) []byte {
+		b := []byte{0xFF, 0xFE}
+		for _, unit := range utf16.Encode([]rune(s)) {
+			b = append(b, byte(unit), byte(unit>>8))
+		}
+		return b
+	}
+
+	t.Run("with a UTF-8 BOM", func(t *testing.T) {
+		r := NewParallelReader()
+		r.StripBOM = true
+
+		assert.Equal("id,name\n1,abc\n", read(r, append([]byte{0xEF, 0xBB, 0xBF}, "id,name\n1,abc\n"...)))
+	})
+
+	t.Run("without a BOM", func(t *testing.T) {
+		r := NewParallelReader()
+		r.StripBOM = true
+
+		assert.Equal("id,name\n", read(r, []byte("id,name\n")))
+	})
+
+	t.Run("with a UTF-16 BOM", func(t *testing.T) {
+		r := NewParallelReader()
+		r.StripBOM = true
+
+		assert.Equal("a\x00\n\x00", read(r, encodeUTF16LE("a\n")))
+	})
+
+	t.Run("with TranscodeUTF16", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+		r.StripBOM = true
+		r.TranscodeUTF16 = true
+
+		input := strings.Repeat("héllo 😀\n", 10)
+		assert.Equal(input, read(r, encodeUTF16LE(input)))
+	})
+
+	t.Run("when StripBOM isn't set", func(t *testing.T) {
+		r := NewParallelReader()
+
+		assert.Equal("\xEF\xBB\xBFabc\n", read(r, []byte("\xEF\xBB\xBFabc\n")))
+	})
+}
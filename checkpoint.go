@@ -0,0 +1,160 @@
+package rip
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// How often offsets are written to Checkpoint when CheckpointInterval is 0.
+const defaultCheckpointInterval = time.Second
+
+// checkpointer tracks the end of the longest run of chunks, from the start of
+// a read, that have all been processed, and writes it to Checkpoint every
+// CheckpointInterval while it advances. Chunks finish out of order, so those
+// that finish ahead of a chunk still being processed wait in pending until it
+// catches up.
+type checkpointer struct {
+	w    io.Writer
+	base int64
+
+	mu      sync.Mutex
+	next    int
+	pending map[int]int64
+	offset  int64
+	failed  bool
+
+	written int64
+	err     error
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// startCheckpoints starts writing checkpoints for a run if Checkpoint is set,
+// returning nil if it isn't. Offsets are counted from base.
+func (r *ParallelReader) startCheckpoints(base int64) *checkpointer {
+	if r.Checkpoint == nil || r.Reverse {
+		return nil
+	}
+
+	p := &checkpointer{
+		w:       r.Checkpoint,
+		base:    base,
+		pending: make(map[int]int64),
+		offset:  base,
+		written: base,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	interval := r.CheckpointInterval
+	if interval <= 0 {
+		interval = defaultCheckpointInterval
+	}
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.write()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+
+	return p
+}
+
+// dropped records that n bytes at the start of the stream were dropped before
+// it was split into chunks, by StripBOM or SkipToFirstBoundary, so that the
+// offsets written are still positions in the stream. It must be called before
+// the first chunk is sent.
+func (p *checkpointer) dropped(n int64) {
+	p.base += n
+	p.offset += n
+	p.written += n
+}
+
+// done records that c has been processed, successfully if ok. Once a chunk
+// hasn't been, the run has failed, and the offset doesn't advance again, even
+// past chunks that were processed after it, since resuming has to start with
+// the one that failed.
+func (p *checkpointer) done(c *chunk, ok bool) {
+	end := p.base + c.offset + int64(c.readableSize-c.lookahead)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !ok {
+		p.failed = true
+	}
+	if p.failed {
+		return
+	}
+	if c.index != p.next {
+		p.pending[c.index] = end
+		return
+	}
+	p.offset = end
+	p.next++
+	for {
+		end, ok := p.pending[p.next]
+		if !ok {
+			break
+		}
+		delete(p.pending, p.next)
+		p.offset = end
+		p.next++
+	}
+}
+
+// write writes the current offset, if it's moved on since the last one that
+// was written. Once a write has failed, no more are attempted.
+func (p *checkpointer) write() {
+	p.mu.Lock()
+	offset := p.offset
+	p.mu.Unlock()
+
+	if offset == p.written || p.err != nil {
+		return
+	}
+	// Each offset is written in one go, so that a crash can leave at most the
+	// last line partly written.
+	if _, err := p.w.Write(append(strconv.AppendInt(nil, offset, 10), '\n')); err != nil {
+		p.err = err
+		return
+	}
+	p.written = offset
+}
+
+// finish stops the periodic writes once every chunk has been processed, and
+// writes the final offset, returning the first error writing any of them.
+func (p *checkpointer) finish() error {
+	close(p.stop)
+	<-p.stopped
+	p.write()
+	return p.err
+}
+
+// ReadCheckpoint returns the last offset written to a Checkpoint, from data
+// read back from it, for passing to ReadFromOffset to resume a read that was
+// interrupted. A final line that was only partly written when the process
+// stopped is ignored in favour of the one before it. If there's no checkpoint
+// at all, the read is resumed from the start, at offset 0.
+func ReadCheckpoint(checkpoint io.Reader) (int64, error) {
+	data, err := io.ReadAll(checkpoint)
+	if err != nil {
+		return 0, err
+	}
+
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	if len(data) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(data[bytes.LastIndexByte(data, '\n')+1:]), 10, 64)
+}
@@ -0,0 +1,229 @@
+package rip
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	offsets := func(out string) []int64 {
+		var offsets []int64
+		for _, line := range strings.Fields(out) {
+			offset, err := strconv.ParseInt(line, 10, 64)
+			assert.NoError(err)
+			offsets = append(offsets, offset)
+		}
+		return offsets
+	}
+
+	t.Run("only writes offsets every chunk before has been processed up to", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 4
+		r.ChunkSize = 4
+		r.CheckpointInterval = time.Millisecond
+
+		var out lockedBuffer
+		r.Checkpoint = &out
+
+		input := strings.Repeat("abc\n", 100)
+		var writtenWhileSlow int
+		r.Read(strings.NewReader("slo\n"+input), func(chunk []byte) {
+			if string(chunk) == "slo\n" {
+				time.Sleep(50 * time.Millisecond)
+				writtenWhileSlow = out.Len()
+			}
+		})
+
+		assert.Zero(writtenWhileSlow)
+		written := offsets(out.String())
+		if assert.NotEmpty(written) {
+			assert.IsIncreasing(written)
+			assert.EqualValues(4+len(input), written[len(written)-1])
+		}
+		for _, offset := range written {
+			assert.Zero(offset%4, "offset %d isn't at the end of a chunk", offset)
+		}
+	})
+
+	t.Run("resumes with ReadCheckpoint and ReadFromOffset", func(t *testing.T) {
+		input := strings.Repeat("abc\n", 100)
+
+		var out bytes.Buffer
+		out.WriteString("40\n")
+
+		offset, err := ReadCheckpoint(bytes.NewReader(out.Bytes()))
+		if !assert.NoError(err) {
+			return
+		}
+		assert.EqualValues(40, offset)
+
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.Checkpoint = &out
+
+		var mu sync.Mutex
+		var first int64 = -1
+		assert.NoError(r.ReadFromOffset(strings.NewReader(input), offset, func(offset int64, chunk []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			if offset < first || first < 0 {
+				first = offset
+			}
+		}))
+		assert.EqualValues(40, first)
+
+		last, err := ReadCheckpoint(&out)
+		assert.NoError(err)
+		assert.EqualValues(len(input), last)
+	})
+
+	t.Run("with Lookahead", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.Lookahead = 2
+		r.CheckpointInterval = time.Millisecond
+
+		var out lockedBuffer
+		r.Checkpoint = &out
+
+		input := strings.Repeat("ab\n", 100)
+		r.Read(strings.NewReader(input), func(chunk []byte) {
+			time.Sleep(100 * time.Microsecond)
+		})
+
+		for _, offset := range offsets(out.String()) {
+			assert.Zero(offset%3, "offset %d isn't at the end of a record", offset)
+		}
+		last, err := ReadCheckpoint(strings.NewReader(out.String()))
+		assert.NoError(err)
+		assert.EqualValues(len(input), last)
+	})
+
+	t.Run("counts a stripped BOM", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.StripBOM = true
+
+		var out lockedBuffer
+		r.Checkpoint = &out
+
+		input := "\xEF\xBB\xBFaaa\nbbb\nccc\n"
+		r.Read(strings.NewReader(input), func(chunk []byte) {})
+
+		last, err := ReadCheckpoint(strings.NewReader(out.String()))
+		assert.NoError(err)
+		assert.EqualValues(len(input), last)
+	})
+
+	t.Run("counts what SkipToFirstBoundary skips", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.SkipToFirstBoundary = true
+
+		var out lockedBuffer
+		r.Checkpoint = &out
+
+		input := "xx\naaa\nbbb\n"
+		r.Read(strings.NewReader(input), func(chunk []byte) {})
+
+		last, err := ReadCheckpoint(strings.NewReader(out.String()))
+		assert.NoError(err)
+		assert.EqualValues(len(input), last)
+	})
+
+	t.Run("stops before a chunk whose callback failed", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 1
+		r.ChunkSize = 4
+
+		var out bytes.Buffer
+		r.Checkpoint = &out
+
+		errFailed := errors.New("failed")
+		err := r.ReadContextWork(context.Background(), strings.NewReader("abc\ndef\nghi\njkl\n"), func(ctx context.Context, chunk []byte) error {
+			if string(chunk) == "ghi\n" {
+				return errFailed
+			}
+			return nil
+		})
+		assert.ErrorIs(err, errFailed)
+
+		last, err := ReadCheckpoint(&out)
+		assert.NoError(err)
+		assert.EqualValues(8, last)
+	})
+
+	t.Run("when every callback fails", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var out bytes.Buffer
+		r.Checkpoint = &out
+
+		err := r.ReadContextWork(context.Background(), strings.NewReader("abc\ndef\nghi\n"), func(ctx context.Context, chunk []byte) error {
+			return errors.New("failed")
+		})
+		assert.Error(err)
+		assert.Empty(out.String())
+	})
+
+	t.Run("stops before a chunk whose callback panicked", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 1
+		r.ChunkSize = 4
+		r.RecoverPanics = true
+
+		var out bytes.Buffer
+		r.Checkpoint = &out
+
+		result := r.Read(strings.NewReader("abc\ndef\nghi\njkl\n"), func(chunk []byte) {
+			if string(chunk) == "ghi\n" {
+				panic("boom")
+			}
+		})
+		var panicErr *PanicError
+		assert.ErrorAs(result.Err, &panicErr)
+
+		last, err := ReadCheckpoint(&out)
+		assert.NoError(err)
+		assert.EqualValues(8, last)
+	})
+
+	t.Run("fails the read when a checkpoint can't be written", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RecoverPanics = true
+		r.Checkpoint = failingWriter{}
+
+		result := r.Read(strings.NewReader("abc\ndef\n"), func(chunk []byte) {})
+		assert.ErrorIs(result.Err, errWrite)
+	})
+}
+
+func TestReadCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	for checkpoint, expected := range map[string]int64{
+		"":            0,
+		"12":          0,
+		"12\n":        12,
+		"12\n345\n":   345,
+		"12\n345\n67": 345,
+	} {
+		offset, err := ReadCheckpoint(strings.NewReader(checkpoint))
+		assert.NoError(err, "%q", checkpoint)
+		assert.Equal(expected, offset, "%q", checkpoint)
+	}
+
+	_, err := ReadCheckpoint(strings.NewReader("12\nabc\n"))
+	assert.Error(err)
+}
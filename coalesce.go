@@ -0,0 +1,77 @@
+package rip
+
+// finalCoalescer sits between the scanner and emit, holding back chunks until
+// it's known whether a small chunk is the final one, so that it can be merged
+// into the chunk before it. At most two chunks are held at once: a chunk is
+// only held alongside the one before it if it's smaller than minSize.
+type finalCoalescer struct {
+	pool    *Pool
+	minSize int
+	emit    func(c *chunk) bool
+	held    []*chunk
+}
+
+// add holds c, emitting any held chunks that can no longer be merged. It
+// returns false if emitting was stopped.
+func (f *finalCoalescer) add(c *chunk) bool {
+	// A small chunk followed by another wasn't the final chunk after all.
+	if len(f.held) == 2 && !f.emitHeld() {
+		return f.release(c)
+	}
+
+	if len(f.held) == 1 && c.readableSize >= f.minSize {
+		if !f.emitHeld() {
+			return f.release(c)
+		}
+	}
+
+	f.held = append(f.held, c)
+	return true
+}
+
+// flush emits the held chunks at the end of the stream, merging the final chunk
+// into the one before it if it's small.
+func (f *finalCoalescer) flush() bool {
+	if len(f.held) == 2 {
+		prev, final := f.held[0], f.held[1]
+
+		// With Lookahead, the end of prev is the start of final, so it's only
+		// kept once.
+		prevSize := min(prev.readableSize, int(final.offset-prev.offset))
+		merged := f.pool.alloc(prevSize + final.readableSize)
+		copy(merged, prev.ReadableBytes()[:prevSize])
+		copy(merged[prevSize:], final.ReadableBytes())
+		f.pool.Return(prev.buffer)
+		f.pool.Return(final.buffer)
+
+		prev.buffer, prev.readableSize = merged, len(merged)
+		f.held = f.held[:1]
+	}
+
+	return f.emitHeld()
+}
+
+// emitHeld emits all held chunks in order.
+func (f *finalCoalescer) emitHeld() bool {
+	for i, c := range f.held {
+		if !f.emit(c) {
+			f.held = f.held[i+1:]
+			return f.release(nil)
+		}
+	}
+	f.held = f.held[:0]
+	return true
+}
+
+// release returns the buffers of the held chunks, and c if not nil, to the
+// pool after emitting was stopped. It always returns false.
+func (f *finalCoalescer) release(c *chunk) bool {
+	if c != nil {
+		f.held = append(f.held, c)
+	}
+	for _, c := range f.held {
+		f.pool.Return(c.buffer)
+	}
+	f.held = nil
+	return false
+}
@@ -0,0 +1,94 @@
+package rip
+
+import "io"
+
+// CollectFirst reads stream using r, calling fn for each chunk from a pool of
+// goroutines, and returns the first n values that fn chose to keep, in the order
+// their chunks appear in stream. As soon as the first n are known, the rest of
+// the read is cancelled. If fewer than n values are kept, all of them are
+// returned by the time the stream ends.
+//
+// Values are put back in order using a reorder window of MaxReorderBuffer
+// results, as with Transform, so memory is bounded by n and the window. Like
+// Read, CollectFirst panics if stream returns an error. It's a function rather
+// than a method because Go methods can't have type parameters.
+func CollectFirst[T any](r *ParallelReader, stream io.Reader, fn func(chunk []byte) (T, bool), n int) []T {
+	if n <= 0 {
+		return nil
+	}
+
+	type result struct {
+		index int
+		value T
+		keep  bool
+	}
+
+	results := make(chan result, r.Concurrency)
+	done := make(chan struct{})
+	window := r.newReorderWindow()
+
+	var collected []T
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+
+		pending := make(map[int]result)
+		next := 0
+		for res := range results {
+			// Once enough have been collected, drain the rest so that workers don't
+			// block.
+			if len(collected) == n {
+				continue
+			}
+			pending[res.index] = res
+
+			for {
+				res, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+
+				if res.keep {
+					collected = append(collected, res.value)
+				}
+				if len(collected) == n {
+					close(done)
+					window.release()
+					break
+				}
+				window.advance(next)
+			}
+		}
+	}()
+
+	// A chunk that Middleware skipped keeps no value.
+	defer r.inOrder(func(c *chunk) {
+		window.wait(c.index)
+		results <- result{index: c.index}
+	})()
+
+	err := r.run(stream, done, func(c *chunk) {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		sent := false
+		defer window.abandon(&sent)
+
+		value, keep := fn(c.ReadableBytes())
+		window.wait(c.index)
+		results <- result{index: c.index, value: value, keep: keep}
+		sent = true
+	})
+	close(results)
+	<-finished
+
+	if err != nil {
+		panic(err)
+	}
+	return collected
+}
@@ -0,0 +1,50 @@
+package rip
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectFirst(t *testing.T) {
+	assert := assert.New(t)
+
+	var input strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&input, "%04d\n", i)
+	}
+
+	parseEven := func(calls *int64) func(chunk []byte) (int, bool) {
+		return func(chunk []byte) (int, bool) {
+			atomic.AddInt64(calls, 1)
+			n, _ := strconv.Atoi(string(bytes.TrimSpace(chunk)))
+			return n, n%2 == 0
+		}
+	}
+
+	t.Run("returns the first n kept values in order", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 5
+
+		var calls int64
+		values := CollectFirst(r, strings.NewReader(input.String()), parseEven(&calls), 5)
+
+		assert.Equal([]int{0, 2, 4, 6, 8}, values)
+		assert.Less(atomic.LoadInt64(&calls), int64(10000))
+	})
+
+	t.Run("when fewer than n values are kept", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 5
+
+		var calls int64
+		values := CollectFirst(r, strings.NewReader("0001\n0002\n0003\n"), parseEven(&calls), 5)
+
+		assert.Equal([]int{2}, values)
+	})
+}
@@ -0,0 +1,109 @@
+package rip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// BlockIndex describes where one fixed size block of input ended up in the
+// output written by CompressTo.
+type BlockIndex struct {
+	InputOffset  int64
+	InputLen     int
+	OutputOffset int64
+	OutputLen    int
+}
+
+// CompressTo splits in into fixed size blocks of ChunkSize like ReadFixed,
+// compresses each of them in parallel with compress, and writes the compressed
+// blocks to out in input order. It returns an index of every block's position
+// in both the input and output, which is enough to build a seekable compressed
+// file: to read from a given input offset, find the block containing it and
+// decompress from that block's OutputOffset.
+//
+// compress must produce independently decompressible output for each block,
+// e.g. a complete gzip member.
+func (r *ParallelReader) CompressTo(in io.Reader, out io.Writer, compress func(block []byte) []byte) ([]BlockIndex, error) {
+	var index []BlockIndex
+	var outputOffset int64
+
+	results := make(chan *chunkResult, r.Concurrency)
+	done := make(chan struct{})
+	w := &orderedWriter{out: out, window: r.newReorderWindow(), flushInterval: r.FlushInterval, written: func(res *chunkResult) {
+		if res.skipped {
+			return
+		}
+		index = append(index, BlockIndex{
+			InputOffset:  res.offset,
+			InputLen:     res.size,
+			OutputOffset: outputOffset,
+			OutputLen:    len(res.output),
+		})
+		outputOffset += int64(len(res.output))
+	}}
+
+	writeErr := make(chan error, 1)
+	go func() {
+		writeErr <- w.writeAll(results, done)
+	}()
+
+	defer r.inOrder(func(c *chunk) {
+		w.window.wait(c.index)
+		results <- &chunkResult{index: c.index, skipped: true}
+	})()
+
+	readErr := r.runFixed(in, done, func(c *chunk) {
+		sent := false
+		defer w.window.abandon(&sent)
+
+		output := compress(c.ReadableBytes())
+		w.window.wait(c.index)
+		results <- &chunkResult{
+			index:  c.index,
+			offset: c.offset,
+			size:   c.readableSize,
+			output: output,
+		}
+		sent = true
+	})
+	close(results)
+
+	if err := <-writeErr; err != nil {
+		return index, err
+	}
+	return index, readErr
+}
+
+// ReadCompressed is like Read, but gzip-compresses each chunk on the worker
+// goroutine before passing it to the callback, for tools that write their own
+// framing around independently compressed chunks. Each chunk becomes a
+// complete gzip member. Writers and output buffers are pooled rather than
+// allocated per chunk, so compressed is only valid until the callback returns.
+func (r *ParallelReader) ReadCompressed(stream io.Reader, work func(compressed []byte)) Result {
+	var writers sync.Pool
+
+	return r.Read(stream, func(chunk []byte) {
+		gz, _ := writers.Get().(*gzipWriter)
+		if gz == nil {
+			gz = &gzipWriter{}
+			gz.Writer = gzip.NewWriter(&gz.buf)
+		}
+		defer writers.Put(gz)
+
+		gz.buf.Reset()
+		gz.Reset(&gz.buf)
+		// Writes to a bytes.Buffer can't fail.
+		gz.Write(chunk)
+		gz.Close()
+
+		work(gz.buf.Bytes())
+	})
+}
+
+// A gzip.Writer along with the buffer it writes to.
+type gzipWriter struct {
+	*gzip.Writer
+	buf bytes.Buffer
+}
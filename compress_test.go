@@ -0,0 +1,78 @@
+package rip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressTo(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewParallelReader()
+	r.ChunkSize = 1 << 10
+
+	input := strings.Repeat("the quick brown fox jumps over the lazy dog\n", 100)
+
+	var out bytes.Buffer
+	index, err := r.CompressTo(strings.NewReader(input), &out, func(block []byte) []byte {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write(block)
+		w.Close()
+		return buf.Bytes()
+	})
+
+	assert.NoError(err)
+	if !assert.Len(index, 5) {
+		return
+	}
+	assert.EqualValues(4<<10, index[4].InputOffset)
+	assert.Equal(len(input)-4<<10, index[4].InputLen)
+
+	// Each block can be decompressed on its own from its output offset.
+	var expectedOutputOffset int64
+	for _, block := range index {
+		assert.Equal(expectedOutputOffset, block.OutputOffset)
+		expectedOutputOffset += int64(block.OutputLen)
+
+		section := bytes.NewReader(out.Bytes()[block.OutputOffset : block.OutputOffset+int64(block.OutputLen)])
+		gz, err := gzip.NewReader(section)
+		if !assert.NoError(err) {
+			continue
+		}
+		gz.Multistream(false)
+		data, _ := io.ReadAll(gz)
+		assert.Equal(input[block.InputOffset:block.InputOffset+int64(block.InputLen)], string(data))
+	}
+	assert.EqualValues(out.Len(), expectedOutputOffset)
+}
+
+func TestReadCompressed(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewParallelReader()
+	r.ChunkSize = 64
+
+	input := strings.Repeat("the quick brown fox jumps over the lazy dog\n", 100)
+
+	chunks := make(chan string, 128)
+	r.ReadCompressed(strings.NewReader(input), func(compressed []byte) {
+		gz, err := gzip.NewReader(bytes.NewReader(compressed))
+		if !assert.NoError(err) {
+			return
+		}
+		chunk, err := io.ReadAll(gz)
+		assert.NoError(err)
+		chunks <- string(chunk)
+	})
+	close(chunks)
+
+	results := drain(chunks)
+	assert.Len(results, 100)
+	assert.Equal(input, strings.Join(results, ""))
+}
@@ -0,0 +1,343 @@
+package rip
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// configFields maps each key accepted by NewParallelReaderFromMap to a function
+// that validates its value and sets the corresponding field.
+var configFields = map[string]func(r *ParallelReader, value any) error{
+	"concurrency":               positiveInt(func(r *ParallelReader, v int) { r.Concurrency = v }),
+	"chunk_size":                positiveInt(func(r *ParallelReader, v int) { r.ChunkSize = v }),
+	"chunk_boundary":            str(func(r *ParallelReader, v string) { r.ChunkBoundary = v }),
+	"chunk_boundary_start":      str(func(r *ParallelReader, v string) { r.ChunkBoundaryStart = v }),
+	"require_boundary":          boolean(func(r *ParallelReader, v bool) { r.RequireBoundary = v }),
+	"first_boundary":            str(func(r *ParallelReader, v string) { r.FirstBoundary = v }),
+	"chunk_boundaries":          strs(func(r *ParallelReader, v []string) { r.ChunkBoundaries = v }),
+	"emit_empty_final_chunk":    boolean(func(r *ParallelReader, v bool) { r.EmitEmptyFinalChunk = v }),
+	"pool_size":                 nonNegativeInt(func(r *ParallelReader, v int) { r.PoolSize = v }),
+	"read_buffer_size":          nonNegativeInt(func(r *ParallelReader, v int) { r.ReadBufferSize = v }),
+	"parallel_scan":             boolean(func(r *ParallelReader, v bool) { r.ParallelScan = v }),
+	"reverse":                   boolean(func(r *ParallelReader, v bool) { r.Reverse = v }),
+	"auto_chunk_size":           boolean(func(r *ParallelReader, v bool) { r.AutoChunkSize = v }),
+	"records_per_chunk":         nonNegativeInt(func(r *ParallelReader, v int) { r.RecordsPerChunk = v }),
+	"profile":                   boolean(func(r *ParallelReader, v bool) { r.Profile = v }),
+	"lock_worker_threads":       boolean(func(r *ParallelReader, v bool) { r.LockWorkerThreads = v }),
+	"buffer_alignment":          nonNegativeInt(func(r *ParallelReader, v int) { r.BufferAlignment = v }),
+	"poison_buffers":            boolean(func(r *ParallelReader, v bool) { r.PoisonBuffers = v }),
+	"max_reorder_buffer":        nonNegativeInt(func(r *ParallelReader, v int) { r.MaxReorderBuffer = v }),
+	"retry_read":                nonNegativeInt(func(r *ParallelReader, v int) { r.RetryRead = v }),
+	"retry_work":                nonNegativeInt(func(r *ParallelReader, v int) { r.RetryWork = v }),
+	"retry_backoff":             duration(func(r *ParallelReader, v time.Duration) { r.RetryBackoff = v }),
+	"output_separator":          str(func(r *ParallelReader, v string) { r.OutputSeparator = []byte(v) }),
+	"max_chunk_size":            nonNegativeInt(func(r *ParallelReader, v int) { r.MaxChunkSize = v }),
+	"lookahead":                 nonNegativeInt(func(r *ParallelReader, v int) { r.Lookahead = v }),
+	"coalesce_final":            boolean(func(r *ParallelReader, v bool) { r.CoalesceFinal = v }),
+	"min_chunk_size":            nonNegativeInt(func(r *ParallelReader, v int) { r.MinChunkSize = v }),
+	"max_bytes":                 nonNegativeInt(func(r *ParallelReader, v int) { r.MaxBytes = int64(v) }),
+	"fail_on_max_bytes":         boolean(func(r *ParallelReader, v bool) { r.FailOnMaxBytes = v }),
+	"expected_records":          nonNegativeInt(func(r *ParallelReader, v int) { r.ExpectedRecords = int64(v) }),
+	"batch_size":                nonNegativeInt(func(r *ParallelReader, v int) { r.BatchSize = v }),
+	"case_insensitive_boundary": boolean(func(r *ParallelReader, v bool) { r.CaseInsensitiveBoundary = v }),
+	"max_line_length":           nonNegativeInt(func(r *ParallelReader, v int) { r.MaxLineLength = v }),
+	"min_parallel_size":         nonNegativeInt(func(r *ParallelReader, v int) { r.MinParallelSize = v }),
+	"sample_every":              nonNegativeInt(func(r *ParallelReader, v int) { r.SampleEvery = v }),
+	"sample_rate":               fraction(func(r *ParallelReader, v float64) { r.SampleRate = v }),
+	"sample_seed":               nonNegativeInt(func(r *ParallelReader, v int) { r.SampleSeed = int64(v) }),
+	"flush_interval":            duration(func(r *ParallelReader, v time.Duration) { r.FlushInterval = v }),
+	"checkpoint_interval":       duration(func(r *ParallelReader, v time.Duration) { r.CheckpointInterval = v }),
+	"shutdown_grace":            duration(func(r *ParallelReader, v time.Duration) { r.ShutdownGrace = v }),
+	"no_copy":                   boolean(func(r *ParallelReader, v bool) { r.NoCopy = v }),
+	"count_lines":               boolean(func(r *ParallelReader, v bool) { r.CountLines = v }),
+	"prefetch":                  nonNegativeInt(func(r *ParallelReader, v int) { r.Prefetch = v }),
+	"recover_panics":            boolean(func(r *ParallelReader, v bool) { r.RecoverPanics = v }),
+	"normalize_line_endings":    boolean(func(r *ParallelReader, v bool) { r.NormalizeLineEndings = v }),
+	"strip_bom":                 boolean(func(r *ParallelReader, v bool) { r.StripBOM = v }),
+	"reject_binary":             boolean(func(r *ParallelReader, v bool) { r.RejectBinary = v }),
+	"skip_to_first_boundary":    boolean(func(r *ParallelReader, v bool) { r.SkipToFirstBoundary = v }),
+	"transcode_utf16":           boolean(func(r *ParallelReader, v bool) { r.TranscodeUTF16 = v }),
+}
+
+// NewParallelReaderFromMap returns a ParallelReader with the defaults of
+// NewParallelReader, overridden by the settings in m, such as a section of a
+// JSON or YAML config file. Keys are the snake_case names of the exported
+// fields, e.g. "chunk_size" for ChunkSize; fields that hold functions can't be
+// set this way. An error is returned for unknown keys and invalid values, like
+// a chunk_size that isn't a positive integer.
+func NewParallelReaderFromMap(m map[string]any) (*ParallelReader, error) {
+	r := NewParallelReader()
+
+	// Apply settings in a consistent order, so the same config always reports
+	// the same error.
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		set, ok := configFields[key]
+		if !ok {
+			return nil, fmt.Errorf("rip: unknown config key %q", key)
+		}
+		if err := set(r, m[key]); err != nil {
+			return nil, fmt.Errorf("rip: invalid %s: %w", key, err)
+		}
+	}
+
+	return r, nil
+}
+
+// ReaderConfig is a snapshot of the settings a ParallelReader will read with,
+// as returned by Config. Its JSON encoding uses the same keys as
+// NewParallelReaderFromMap.
+type ReaderConfig struct {
+	Concurrency             int      `json:"concurrency"`
+	ChunkSize               int      `json:"chunk_size"`
+	MaxChunkSize            int      `json:"max_chunk_size"`
+	Lookahead               int      `json:"lookahead"`
+	MinChunkSize            int      `json:"min_chunk_size"`
+	ChunkBoundary           string   `json:"chunk_boundary"`
+	ChunkBoundaryStart      string   `json:"chunk_boundary_start"`
+	ChunkBoundaries         []string `json:"chunk_boundaries,omitempty"`
+	FirstBoundary           string   `json:"first_boundary"`
+	RequireBoundary         bool     `json:"require_boundary"`
+	EmitEmptyFinalChunk     bool     `json:"emit_empty_final_chunk"`
+	CoalesceFinal           bool     `json:"coalesce_final"`
+	PoolSize                int      `json:"pool_size"`
+	ReadBufferSize          int      `json:"read_buffer_size"`
+	ParallelScan            bool     `json:"parallel_scan"`
+	Reverse                 bool     `json:"reverse"`
+	AutoChunkSize           bool     `json:"auto_chunk_size"`
+	RecordsPerChunk         int      `json:"records_per_chunk"`
+	Profile                 bool     `json:"profile"`
+	LockWorkerThreads       bool     `json:"lock_worker_threads"`
+	BufferAlignment         int      `json:"buffer_alignment"`
+	PoisonBuffers           bool     `json:"poison_buffers"`
+	MaxReorderBuffer        int      `json:"max_reorder_buffer"`
+	RetryRead               int      `json:"retry_read"`
+	RetryWork               int      `json:"retry_work"`
+	RetryBackoff            string   `json:"retry_backoff"`
+	OutputSeparator         string   `json:"output_separator,omitempty"`
+	StripBOM                bool     `json:"strip_bom"`
+	SkipToFirstBoundary     bool     `json:"skip_to_first_boundary"`
+	RejectBinary            bool     `json:"reject_binary"`
+	TranscodeUTF16          bool     `json:"transcode_utf16"`
+	NormalizeLineEndings    bool     `json:"normalize_line_endings"`
+	MaxBytes                int64    `json:"max_bytes"`
+	FailOnMaxBytes          bool     `json:"fail_on_max_bytes"`
+	ExpectedRecords         int64    `json:"expected_records"`
+	BatchSize               int      `json:"batch_size"`
+	RecoverPanics           bool     `json:"recover_panics"`
+	Prefetch                int      `json:"prefetch"`
+	CountLines              bool     `json:"count_lines"`
+	CaseInsensitiveBoundary bool     `json:"case_insensitive_boundary"`
+	NoCopy                  bool     `json:"no_copy"`
+	FlushInterval           string   `json:"flush_interval"`
+	MinParallelSize         int      `json:"min_parallel_size"`
+	MaxLineLength           int      `json:"max_line_length"`
+	SampleEvery             int      `json:"sample_every"`
+	SampleRate              float64  `json:"sample_rate"`
+	SampleSeed              int64    `json:"sample_seed"`
+	ShutdownGrace           string   `json:"shutdown_grace"`
+	CheckpointInterval      string   `json:"checkpoint_interval"`
+}
+
+// Config returns the settings r will read with, with the defaults that apply
+// to fields left at zero filled in: PoolSize, MaxChunkSize, RecordsPerChunk
+// (when AutoChunkSize is set) and MaxReorderBuffer. ParallelScan is reported
+// as false if another setting disables it. This is meant for logging what a
+// reader will actually do; it doesn't change r.
+func (r *ParallelReader) Config() ReaderConfig {
+	c := ReaderConfig{
+		Concurrency:             r.Concurrency,
+		ChunkSize:               r.ChunkSize,
+		MaxChunkSize:            r.maxChunkSize(),
+		Lookahead:               r.Lookahead,
+		MinChunkSize:            r.MinChunkSize,
+		ChunkBoundary:           r.ChunkBoundary,
+		ChunkBoundaryStart:      r.ChunkBoundaryStart,
+		ChunkBoundaries:         append([]string(nil), r.ChunkBoundaries...),
+		FirstBoundary:           r.FirstBoundary,
+		RequireBoundary:         r.RequireBoundary,
+		EmitEmptyFinalChunk:     r.EmitEmptyFinalChunk,
+		CoalesceFinal:           r.CoalesceFinal,
+		PoolSize:                r.poolSize(),
+		ReadBufferSize:          r.ReadBufferSize,
+		ParallelScan:            r.parallelScan(),
+		Reverse:                 r.Reverse,
+		AutoChunkSize:           r.AutoChunkSize,
+		RecordsPerChunk:         r.RecordsPerChunk,
+		Profile:                 r.Profile,
+		LockWorkerThreads:       r.LockWorkerThreads,
+		BufferAlignment:         r.BufferAlignment,
+		PoisonBuffers:           r.PoisonBuffers,
+		MaxReorderBuffer:        r.newReorderWindow().size,
+		RetryRead:               r.RetryRead,
+		RetryWork:               r.RetryWork,
+		RetryBackoff:            r.RetryBackoff.String(),
+		OutputSeparator:         string(r.OutputSeparator),
+		StripBOM:                r.StripBOM,
+		SkipToFirstBoundary:     r.SkipToFirstBoundary,
+		RejectBinary:            r.RejectBinary,
+		TranscodeUTF16:          r.TranscodeUTF16,
+		NormalizeLineEndings:    r.NormalizeLineEndings,
+		MaxBytes:                r.MaxBytes,
+		FailOnMaxBytes:          r.FailOnMaxBytes,
+		ExpectedRecords:         r.ExpectedRecords,
+		BatchSize:               r.BatchSize,
+		RecoverPanics:           r.RecoverPanics,
+		Prefetch:                r.Prefetch,
+		CountLines:              r.CountLines,
+		CaseInsensitiveBoundary: r.CaseInsensitiveBoundary,
+		NoCopy:                  r.NoCopy,
+		FlushInterval:           r.FlushInterval.String(),
+		MinParallelSize:         r.MinParallelSize,
+		MaxLineLength:           r.MaxLineLength,
+		SampleEvery:             r.SampleEvery,
+		SampleRate:              r.SampleRate,
+		SampleSeed:              r.SampleSeed,
+		ShutdownGrace:           r.ShutdownGrace.String(),
+		CheckpointInterval:      r.CheckpointInterval.String(),
+	}
+
+	if r.AutoChunkSize && c.RecordsPerChunk <= 0 {
+		c.RecordsPerChunk = defaultRecordsPerChunk
+	}
+	return c
+}
+
+func positiveInt(set func(*ParallelReader, int)) func(*ParallelReader, any) error {
+	return func(r *ParallelReader, value any) error {
+		v, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		if v <= 0 {
+			return fmt.Errorf("must be greater than 0, got %d", v)
+		}
+		set(r, v)
+		return nil
+	}
+}
+
+func nonNegativeInt(set func(*ParallelReader, int)) func(*ParallelReader, any) error {
+	return func(r *ParallelReader, value any) error {
+		v, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		if v < 0 {
+			return fmt.Errorf("must not be negative, got %d", v)
+		}
+		set(r, v)
+		return nil
+	}
+}
+
+// duration accepts strings in the format of time.ParseDuration, like "100ms".
+func duration(set func(*ParallelReader, time.Duration)) func(*ParallelReader, any) error {
+	return func(r *ParallelReader, value any) error {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a duration string like \"100ms\", got %T", value)
+		}
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		if v < 0 {
+			return fmt.Errorf("must not be negative, got %v", v)
+		}
+		set(r, v)
+		return nil
+	}
+}
+
+// fraction accepts numbers from 0 to 1.
+func fraction(set func(*ParallelReader, float64)) func(*ParallelReader, any) error {
+	return func(r *ParallelReader, value any) error {
+		var v float64
+		switch n := value.(type) {
+		case float64:
+			v = n
+		default:
+			i, err := toInt(value)
+			if err != nil {
+				return fmt.Errorf("must be a number, got %T", value)
+			}
+			v = float64(i)
+		}
+		if v < 0 || v > 1 {
+			return fmt.Errorf("must be between 0 and 1, got %v", v)
+		}
+		set(r, v)
+		return nil
+	}
+}
+
+// toInt accepts the integer types YAML decoders produce, as well as float64s
+// with no fractional part, as decoded from JSON.
+func toInt(value any) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("must be an integer, got %v", v)
+		}
+		return int(v), nil
+	default:
+		return 0, fmt.Errorf("must be an integer, got %T", value)
+	}
+}
+
+func str(set func(*ParallelReader, string)) func(*ParallelReader, any) error {
+	return func(r *ParallelReader, value any) error {
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string, got %T", value)
+		}
+		set(r, v)
+		return nil
+	}
+}
+
+func strs(set func(*ParallelReader, []string)) func(*ParallelReader, any) error {
+	return func(r *ParallelReader, value any) error {
+		switch v := value.(type) {
+		case []string:
+			set(r, v)
+		case []any:
+			s := make([]string, len(v))
+			for i, elem := range v {
+				var ok bool
+				if s[i], ok = elem.(string); !ok {
+					return fmt.Errorf("must be a list of strings, got %T at index %d", elem, i)
+				}
+			}
+			set(r, s)
+		default:
+			return fmt.Errorf("must be a list of strings, got %T", value)
+		}
+		return nil
+	}
+}
+
+func boolean(set func(*ParallelReader, bool)) func(*ParallelReader, any) error {
+	return func(r *ParallelReader, value any) error {
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("must be a boolean, got %T", value)
+		}
+		set(r, v)
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+package rip
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewParallelReaderFromMap(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("with settings decoded from JSON", func(t *testing.T) {
+		var m map[string]any
+		json.Unmarshal([]byte(`{
+			"concurrency": 3,
+			"chunk_size": 1024,
+			"chunk_boundary": "|",
+			"chunk_boundaries": ["\n", "\r\n"],
+			"require_boundary": true,
+			"flush_interval": "250ms",
+			"sample_rate": 0.5
+		}`), &m)
+
+		r, err := NewParallelReaderFromMap(m)
+		if !assert.NoError(err) {
+			return
+		}
+		assert.Equal(3, r.Concurrency)
+		assert.Equal(1024, r.ChunkSize)
+		assert.Equal("|", r.ChunkBoundary)
+		assert.Equal([]string{"\n", "\r\n"}, r.ChunkBoundaries)
+		assert.True(r.RequireBoundary)
+		assert.Equal(250*time.Millisecond, r.FlushInterval)
+		assert.Equal(0.5, r.SampleRate)
+	})
+
+	t.Run("keeps defaults for missing keys", func(t *testing.T) {
+		r, err := NewParallelReaderFromMap(map[string]any{})
+
+		assert.NoError(err)
+		assert.Equal(NewParallelReader().ChunkSize, r.ChunkSize)
+	})
+
+	t.Run("with an unknown key", func(t *testing.T) {
+		_, err := NewParallelReaderFromMap(map[string]any{"chunk_sise": 10})
+		assert.EqualError(err, `rip: unknown config key "chunk_sise"`)
+	})
+
+	t.Run("with invalid values", func(t *testing.T) {
+		for _, m := range []map[string]any{
+			{"chunk_size": 0},
+			{"chunk_size": 1.5},
+			{"chunk_size": "64k"},
+			{"pool_size": -1},
+			{"require_boundary": "yes"},
+			{"chunk_boundaries": []any{"\n", 1}},
+			{"sample_rate": 1.5},
+		} {
+			_, err := NewParallelReaderFromMap(m)
+			assert.Error(err, "%v", m)
+		}
+	})
+}
+
+func TestConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("fills in defaults", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 3
+		r.ChunkSize = 1024
+
+		c := r.Config()
+		assert.Equal(3, c.PoolSize)
+		assert.Equal(16*1024, c.MaxChunkSize)
+		assert.Equal(12, c.MaxReorderBuffer)
+		assert.Zero(c.RecordsPerChunk)
+	})
+
+	t.Run("reports the PoolSize the pool is created with", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 3
+		r.Prefetch = 2
+		r.NoCopy = true
+
+		assert.Equal(10, r.Config().PoolSize)
+		assert.Equal(10, cap(r.Pool().pool))
+	})
+
+	t.Run("round trips through NewParallelReaderFromMap", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkBoundaries = []string{"\n", "\r\n"}
+		r.OutputSeparator = []byte(",")
+		r.MaxBytes = 1 << 20
+
+		data, err := json.Marshal(r.Config())
+		if !assert.NoError(err) {
+			return
+		}
+		var m map[string]any
+		json.Unmarshal(data, &m)
+
+		restored, err := NewParallelReaderFromMap(m)
+		if !assert.NoError(err) {
+			return
+		}
+		assert.Equal(r.Config(), restored.Config())
+	})
+}
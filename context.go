@@ -0,0 +1,82 @@
+package rip
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// Stop can be returned by a ReadContextWork callback to stop the run early
+// without it being considered a failure, for example once the record being
+// searched for has been found. ReadContextWork then returns nil.
+var Stop = errors.New("rip: stop")
+
+// ErrShutdownIncomplete is matched by the error ReadContextWork returns when it
+// was stopped but callbacks were still running once ShutdownGrace had passed.
+var ErrShutdownIncomplete = errors.New("rip: callbacks still running after ShutdownGrace")
+
+// ReadContextWork is like Read, but passes each callback a context and stops
+// the run as soon as either ctx is cancelled or a callback returns an error.
+//
+// The context passed to work is a child of ctx that is also cancelled when any
+// callback fails, so long-running callbacks can bail out early. Once the run
+// has been stopped, no further callbacks are started and work already queued is
+// discarded. The first error returned by a callback, or ctx's error if it was
+// cancelled first, is returned; otherwise any error reading stream is. A
+// callback that returns Stop stops the run the same way, but ReadContextWork
+// returns nil. With RetryWork, a callback's error only stops the run once it
+// has used up its retries.
+//
+// Note that cancellation is only noticed between chunks: a blocked Read on the
+// underlying stream won't be interrupted. Nor are callbacks that are already
+// running, which are waited for unless ShutdownGrace is set; if they're still
+// running once it has passed, the error returned matches both
+// ErrShutdownIncomplete and the reason the run was stopped, if there was one
+// other than Stop.
+func (r *ParallelReader) ReadContextWork(ctx context.Context, stream io.Reader, work func(ctx context.Context, chunk []byte) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	r.graceful = true
+	defer func() { r.graceful = false }()
+
+	work = r.retryingWork(work)
+
+	scanErr := r.run(stream, ctx.Done(), func(c *chunk) {
+		if ctx.Err() != nil {
+			c.failed = true
+			return
+		}
+		if err := work(ctx, c.ReadableBytes()); err != nil {
+			c.failed = !errors.Is(err, Stop)
+			cancel(err)
+		}
+	})
+
+	if err := context.Cause(ctx); err != nil {
+		if errors.Is(err, Stop) {
+			err = nil
+		}
+		if errors.Is(scanErr, ErrShutdownIncomplete) {
+			return errors.Join(ErrShutdownIncomplete, err)
+		}
+		return err
+	}
+	return scanErr
+}
+
+// ReadWithTimeout is like Read, but stops the run if it takes longer than d,
+// returning context.DeadlineExceeded. It's ReadContextWork with a context that
+// times out, for callbacks that don't need the context themselves; as there,
+// the timeout is only noticed between chunks, and an error reading stream is
+// returned rather than panicking.
+func (r *ParallelReader) ReadWithTimeout(d time.Duration, stream io.Reader, work func(chunk []byte)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	return r.ReadContextWork(ctx, stream, func(ctx context.Context, chunk []byte) error {
+		work(chunk)
+		return nil
+	})
+}
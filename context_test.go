@@ -0,0 +1,203 @@
+package rip
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadContextWork(t *testing.T) {
+	assert := assert.New(t)
+
+	input := strings.Repeat("abc\n", 1000)
+
+	t.Run("processes every chunk when nothing fails", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var count int64
+		err := r.ReadContextWork(context.Background(), strings.NewReader(input), func(ctx context.Context, chunk []byte) error {
+			atomic.AddInt64(&count, 1)
+			return nil
+		})
+
+		assert.NoError(err)
+		assert.EqualValues(1000, count)
+	})
+
+	t.Run("stops and returns the first callback error", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		errBoom := errors.New("boom")
+		var count int64
+		err := r.ReadContextWork(context.Background(), strings.NewReader(input), func(ctx context.Context, chunk []byte) error {
+			if atomic.AddInt64(&count, 1) == 10 {
+				return errBoom
+			}
+			return nil
+		})
+
+		assert.ErrorIs(err, errBoom)
+		assert.Less(atomic.LoadInt64(&count), int64(1000))
+	})
+
+	t.Run("cancels the context passed to callbacks", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := r.ReadContextWork(ctx, strings.NewReader(input), func(ctx context.Context, chunk []byte) error {
+			cancel()
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		assert.ErrorIs(err, context.Canceled)
+	})
+
+	t.Run("stops without an error when a callback returns Stop", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var count int64
+		err := r.ReadContextWork(context.Background(), strings.NewReader(input), func(ctx context.Context, chunk []byte) error {
+			if atomic.AddInt64(&count, 1) == 10 {
+				return Stop
+			}
+			return nil
+		})
+
+		assert.NoError(err)
+		assert.Less(atomic.LoadInt64(&count), int64(1000))
+	})
+
+	t.Run("with ShutdownGrace and a stuck callback", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ShutdownGrace = 20 * time.Millisecond
+
+		stuck := make(chan struct{})
+		defer close(stuck)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var count int64
+		start := time.Now()
+		err := r.ReadContextWork(ctx, strings.NewReader(input), func(ctx context.Context, chunk []byte) error {
+			if atomic.AddInt64(&count, 1) == 10 {
+				cancel()
+				<-stuck
+			}
+			return nil
+		})
+
+		assert.ErrorIs(err, ErrShutdownIncomplete)
+		assert.ErrorIs(err, context.Canceled)
+		assert.Less(time.Since(start), time.Second)
+
+		// The reader can be used again while the stuck callback is left behind.
+		var again int64
+		err = r.ReadContextWork(context.Background(), strings.NewReader(input), func(ctx context.Context, chunk []byte) error {
+			atomic.AddInt64(&again, 1)
+			return nil
+		})
+		assert.NoError(err)
+		assert.EqualValues(1000, again)
+	})
+
+	t.Run("with ShutdownGrace and a callback that's stuck until the next read", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ShutdownGrace = 20 * time.Millisecond
+		r.RecoverPanics = true
+		r.Profile = true
+
+		stuck := make(chan struct{})
+		left := make(chan struct{})
+		ctx, cancel := context.WithCancel(context.Background())
+		var count int64
+		err := r.ReadContextWork(ctx, strings.NewReader(input), func(ctx context.Context, chunk []byte) error {
+			if atomic.AddInt64(&count, 1) == 10 {
+				cancel()
+				<-stuck
+				close(left)
+			}
+			return nil
+		})
+		assert.ErrorIs(err, ErrShutdownIncomplete)
+
+		// The callback left behind finishes as the next read starts, and mustn't
+		// touch its state.
+		close(stuck)
+		var again int64
+		err = r.ReadContextWork(context.Background(), strings.NewReader(input), func(ctx context.Context, chunk []byte) error {
+			atomic.AddInt64(&again, 1)
+			return nil
+		})
+		assert.NoError(err)
+		assert.EqualValues(1000, again)
+		assert.EqualValues(1000, r.Stats().Chunks)
+		<-left
+	})
+
+	t.Run("with ShutdownGrace and callbacks that finish in time", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ShutdownGrace = time.Second
+
+		var count int64
+		err := r.ReadContextWork(context.Background(), strings.NewReader(input), func(ctx context.Context, chunk []byte) error {
+			if atomic.AddInt64(&count, 1) == 10 {
+				time.Sleep(10 * time.Millisecond)
+				return Stop
+			}
+			return nil
+		})
+
+		assert.NoError(err)
+	})
+}
+
+func TestReadWithTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	input := strings.Repeat("abc\n", 1000)
+
+	t.Run("processes every chunk in time", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var chunks int64
+		err := r.ReadWithTimeout(time.Minute, strings.NewReader(input), func(chunk []byte) {
+			atomic.AddInt64(&chunks, 1)
+		})
+
+		assert.NoError(err)
+		assert.EqualValues(1000, chunks)
+	})
+
+	t.Run("stops once the timeout passes", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 2
+		r.ChunkSize = 4
+
+		var borrowed, returned int64
+		r.Pool().OnBorrow = func([]byte) { atomic.AddInt64(&borrowed, 1) }
+		r.Pool().OnReturn = func([]byte) { atomic.AddInt64(&returned, 1) }
+
+		var chunks int64
+		err := r.ReadWithTimeout(10*time.Millisecond, strings.NewReader(input), func(chunk []byte) {
+			atomic.AddInt64(&chunks, 1)
+			time.Sleep(time.Millisecond)
+		})
+
+		assert.ErrorIs(err, context.DeadlineExceeded)
+		assert.Less(chunks, int64(1000))
+		assert.Equal(borrowed, returned)
+	})
+}
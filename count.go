@@ -0,0 +1,76 @@
+package rip
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRecordCountMismatch is matched by the *RecordCountError a read returns
+// when ExpectedRecords is set and the stream held a different number of
+// records.
+var ErrRecordCountMismatch = errors.New("rip: record count mismatch")
+
+// RecordCountError is returned when ExpectedRecords is set and a read reached
+// the end of the stream having seen a different number of records.
+type RecordCountError struct {
+	Expected int64
+	Actual   int64
+}
+
+func (e *RecordCountError) Error() string {
+	return fmt.Sprintf("rip: expected %d records, but the stream had %d", e.Expected, e.Actual)
+}
+
+func (e *RecordCountError) Unwrap() error {
+	return ErrRecordCountMismatch
+}
+
+// checkRecordCount returns err, or if the read completed without one, a
+// *RecordCountError if it didn't see ExpectedRecords records.
+func (r *ParallelReader) checkRecordCount(err error) error {
+	if err != nil || !r.completed || r.ExpectedRecords <= 0 {
+		return err
+	}
+	if r.stats.Records != r.ExpectedRecords {
+		return &RecordCountError{Expected: r.ExpectedRecords, Actual: r.stats.Records}
+	}
+	return nil
+}
+
+// recordsIn returns the number of records in token, for ExpectedRecords: one
+// per boundary, and one more if token ends with a record that has none, as the
+// last one in a stream may. Unlike countRecords, overlapping candidates in
+// ChunkBoundaries are only counted once.
+func (r *ParallelReader) recordsIn(token []byte) int64 {
+	var n int64
+	switch {
+	case !r.hasBoundary() && r.BoundaryFunc == nil:
+		// Only fixed size chunks, which are a record each.
+		return 1
+	case r.sharedMarker():
+		// Every record starts with a marker.
+		return int64(r.count(token, []byte(r.ChunkBoundary)))
+	case r.BoundaryFunc != nil:
+		for len(token) > 0 {
+			end := r.BoundaryFunc(token, true)
+			if end <= 0 || end > len(token) {
+				break
+			}
+			token = token[end:]
+			n++
+		}
+	default:
+		for len(token) > 0 {
+			end := r.firstBoundaryEnd(token, true)
+			if end <= 0 {
+				break
+			}
+			token = token[end:]
+			n++
+		}
+	}
+	if len(token) > 0 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,103 @@
+package rip
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpectedRecords(t *testing.T) {
+	assert := assert.New(t)
+
+	newReader := func(expected int64) *ParallelReader {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.RecoverPanics = true
+		r.ExpectedRecords = expected
+		return r
+	}
+
+	t.Run("with the expected number of records", func(t *testing.T) {
+		result := newReader(3).Read(strings.NewReader("abc\ndef\nghi\n"), func(chunk []byte) {})
+
+		assert.NoError(result.Err)
+		assert.EqualValues(3, result.Stats.Records)
+	})
+
+	t.Run("with a truncated stream", func(t *testing.T) {
+		result := newReader(3).Read(strings.NewReader("abc\ndef\n"), func(chunk []byte) {})
+
+		assert.ErrorIs(result.Err, ErrRecordCountMismatch)
+		var countErr *RecordCountError
+		if assert.ErrorAs(result.Err, &countErr) {
+			assert.Equal(RecordCountError{Expected: 3, Actual: 2}, *countErr)
+		}
+		assert.EqualError(result.Err, "rip: expected 3 records, but the stream had 2")
+	})
+
+	t.Run("counts an unterminated final record", func(t *testing.T) {
+		result := newReader(3).Read(strings.NewReader("abc\ndef\ngh"), func(chunk []byte) {})
+		assert.NoError(result.Err)
+	})
+
+	t.Run("counts overlapping ChunkBoundaries once", func(t *testing.T) {
+		r := newReader(3)
+		r.ChunkBoundaries = []string{"\n", "\r\n"}
+
+		result := r.Read(strings.NewReader("abc\r\ndef\nghi\r\n"), func(chunk []byte) {})
+		assert.NoError(result.Err)
+	})
+
+	t.Run("counts records skipped by Filter", func(t *testing.T) {
+		r := newReader(3)
+		r.ChunkSize = 4
+		r.Filter = func(chunk []byte) bool { return chunk[0] != 'd' }
+
+		result := r.Read(strings.NewReader("abc\ndef\nghi\n"), func(chunk []byte) {})
+		assert.NoError(result.Err)
+		assert.EqualValues(2, result.Stats.Chunks)
+	})
+
+	t.Run("with a file that's scanned in parallel", func(t *testing.T) {
+		r := newReader(1000)
+		r.Concurrency = 4
+		r.ParallelScan = true
+
+		path := filepath.Join(t.TempDir(), "records")
+		assert.NoError(os.WriteFile(path, []byte(strings.Repeat("abc\n", 1001)), 0o644))
+
+		err := r.ReadFile(path, func(chunk []byte) {})
+		assert.ErrorIs(err, ErrRecordCountMismatch)
+	})
+
+	t.Run("with ReadFixed", func(t *testing.T) {
+		result := newReader(3).ReadFixed(strings.NewReader(strings.Repeat("a", 20)), func(chunk []byte) {})
+		assert.NoError(result.Err)
+	})
+
+	t.Run("with Scan", func(t *testing.T) {
+		stats, err := newReader(2).Scan(strings.NewReader("abc\ndef\nghi\n"))
+
+		assert.ErrorIs(err, ErrRecordCountMismatch)
+		assert.EqualValues(3, stats.Records)
+	})
+
+	t.Run("doesn't check a read that's stopped early", func(t *testing.T) {
+		r := newReader(1)
+		err := r.ReadContextWork(context.Background(), strings.NewReader("abc\ndef\nghi\n"), func(ctx context.Context, chunk []byte) error {
+			return Stop
+		})
+		assert.NoError(err)
+	})
+
+	t.Run("without ExpectedRecords", func(t *testing.T) {
+		result := newReader(0).Read(strings.NewReader("abc\ndef\n"), func(chunk []byte) {})
+
+		assert.NoError(result.Err)
+		assert.Zero(result.Stats.Records)
+	})
+}
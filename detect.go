@@ -0,0 +1,65 @@
+package rip
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ErrNoBoundary is returned by DetectBoundary when no likely record boundary
+// could be found in the sampled bytes.
+var ErrNoBoundary = errors.New("rip: unable to detect a record boundary")
+
+// The number of bytes DetectBoundary samples from the start of a stream.
+const detectSampleSize = 4 << 10 // 4 KiB
+
+// Single byte delimiters DetectBoundary considers when the sample contains no
+// line endings, in order of preference when their counts are tied.
+var delimiterCandidates = []string{"\x1e", "\x00", "\t", "|", ";", ","}
+
+// DetectBoundary peeks at the first few KiB of stream and makes a best-effort
+// guess at its record boundary, suitable for use as ChunkBoundary. The returned
+// reader replays the peeked bytes followed by the rest of stream, and should
+// be used in place of stream from then on.
+//
+// The heuristic is intentionally simple:
+//
+//   - If the sample contains "\n" and every "\n" is preceded by "\r", the
+//     boundary is "\r\n".
+//   - Otherwise, if the sample contains "\n" at all, the boundary is "\n".
+//   - Otherwise, the most frequent of a small set of common delimiters (ASCII
+//     record separator, NUL, tab, '|', ';' and ',') is chosen, provided it
+//     occurs at least twice.
+//
+// If none of these apply, ErrNoBoundary is returned along with the reader. Since
+// only a sample is inspected, the result can be wrong for inputs whose first
+// few KiB aren't representative; it's meant as a convenience for interactive
+// tools rather than something to rely on in a pipeline.
+func DetectBoundary(stream io.Reader) (string, io.Reader, error) {
+	br := bufio.NewReaderSize(stream, detectSampleSize)
+
+	sample, err := br.Peek(detectSampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", br, err
+	}
+
+	if newlines := bytes.Count(sample, []byte("\n")); newlines > 0 {
+		if bytes.Count(sample, []byte("\r\n")) == newlines {
+			return "\r\n", br, nil
+		}
+		return "\n", br, nil
+	}
+
+	best, bestCount := "", 1
+	for _, candidate := range delimiterCandidates {
+		if count := bytes.Count(sample, []byte(candidate)); count > bestCount {
+			best, bestCount = candidate, count
+		}
+	}
+	if best == "" {
+		return "", br, ErrNoBoundary
+	}
+
+	return best, br, nil
+}
@@ -0,0 +1,62 @@
+package rip
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectBoundary(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("with unix line endings", func(t *testing.T) {
+		boundary, rest, err := DetectBoundary(strings.NewReader("abc\ndef\n"))
+
+		assert.NoError(err)
+		assert.Equal("\n", boundary)
+
+		data, _ := io.ReadAll(rest)
+		assert.Equal("abc\ndef\n", string(data))
+	})
+
+	t.Run("with windows line endings", func(t *testing.T) {
+		boundary, _, err := DetectBoundary(strings.NewReader("abc\r\ndef\r\n"))
+
+		assert.NoError(err)
+		assert.Equal("\r\n", boundary)
+	})
+
+	t.Run("with mixed line endings", func(t *testing.T) {
+		boundary, _, err := DetectBoundary(strings.NewReader("abc\r\ndef\n"))
+
+		assert.NoError(err)
+		assert.Equal("\n", boundary)
+	})
+
+	t.Run("with a repeating delimiter", func(t *testing.T) {
+		boundary, _, err := DetectBoundary(strings.NewReader("a|b|c|d,e"))
+
+		assert.NoError(err)
+		assert.Equal("|", boundary)
+	})
+
+	t.Run("when no boundary can be found", func(t *testing.T) {
+		boundary, rest, err := DetectBoundary(strings.NewReader("abcdef"))
+
+		assert.ErrorIs(err, ErrNoBoundary)
+		assert.Equal("", boundary)
+
+		data, _ := io.ReadAll(rest)
+		assert.Equal("abcdef", string(data))
+	})
+
+	t.Run("when the input is larger than the sample", func(t *testing.T) {
+		input := strings.Repeat("x", detectSampleSize) + "\n"
+		_, rest, _ := DetectBoundary(strings.NewReader(input))
+
+		data, _ := io.ReadAll(rest)
+		assert.Equal(input, string(data))
+	})
+}
@@ -0,0 +1,113 @@
+package rip
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+)
+
+// FileError records an error encountered while reading the file at Path.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is returned by ReadFiles when one or more files could not be
+// read, and by ReadZip when one or more archive entries failed. It holds one
+// FileError per failed file or entry, in the order they were given or appear
+// in the archive.
+type MultiError struct {
+	Errors []*FileError
+}
+
+func (e *MultiError) Error() string {
+	return errors.Join(e.errs()...).Error()
+}
+
+// Unwrap allows errors.Is and errors.As to match against any of the
+// underlying file errors.
+func (e *MultiError) Unwrap() []error {
+	return e.errs()
+}
+
+// Paths returns the paths of the files that failed, e.g. so that only those can
+// be retried.
+func (e *MultiError) Paths() []string {
+	paths := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		paths[i] = err.Path
+	}
+	return paths
+}
+
+func (e *MultiError) errs() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ReadFiles calls Read for each of the files at paths in turn, passing the
+// path of the file being read along with each chunk. A file that fails to open
+// or read doesn't stop the others from being processed; instead, all failures
+// are collected and returned together as a *MultiError.
+func (r *ParallelReader) ReadFiles(paths []string, work func(path string, chunk []byte)) error {
+	var failed []*FileError
+
+	for _, path := range paths {
+		if err := r.readFile(path, work); err != nil {
+			failed = append(failed, &FileError{Path: path, Err: err})
+		}
+	}
+
+	if len(failed) > 0 {
+		return &MultiError{Errors: failed}
+	}
+	return nil
+}
+
+// ReadFile opens the file at path and calls Read on it, returning any error
+// opening or reading the file. If ReadBufferSize is set, the file is read
+// through a bufio.Reader of that size, unless it's read with ParallelScan.
+func (r *ParallelReader) ReadFile(path string, work func(chunk []byte)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return r.readOSFile(file, work)
+}
+
+// ReadStdin calls Read on os.Stdin, returning any error reading it, for the
+// convenience of command line tools. Like ReadFile, it respects ReadBufferSize.
+// When stdin is a pipe or terminal rather than a redirected file, it's always
+// scanned sequentially, even if ParallelScan is set.
+func (r *ParallelReader) ReadStdin(work func(chunk []byte)) error {
+	return r.readOSFile(os.Stdin, work)
+}
+
+func (r *ParallelReader) readOSFile(file *os.File, work func(chunk []byte)) error {
+	var stream io.Reader = file
+	if _, ok := seekable(file); r.ReadBufferSize > 0 && !(r.ParallelScan && ok) {
+		stream = bufio.NewReaderSize(file, r.ReadBufferSize)
+	}
+
+	return r.read(stream, work)
+}
+
+func (r *ParallelReader) readFile(path string, work func(path string, chunk []byte)) error {
+	return r.ReadFile(path, func(chunk []byte) {
+		work(path, chunk)
+	})
+}
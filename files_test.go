@@ -0,0 +1,133 @@
+package rip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadFile(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "input.txt")
+	os.WriteFile(path, []byte("abc\ndef\n"), 0644)
+
+	t.Run("with ReadBufferSize", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ReadBufferSize = 1 << 10
+
+		chunks := make(chan string, 128)
+		err := r.ReadFile(path, func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.NoError(err)
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+	})
+
+	t.Run("when the file doesn't exist", func(t *testing.T) {
+		r := NewParallelReader()
+
+		err := r.ReadFile(path+".missing", func(chunk []byte) {})
+		assert.ErrorIs(err, os.ErrNotExist)
+	})
+}
+
+func TestReadStdin(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("when stdin is a pipe", func(t *testing.T) {
+		pr, pw, err := os.Pipe()
+		if !assert.NoError(err) {
+			return
+		}
+		defer pr.Close()
+
+		stdin := os.Stdin
+		os.Stdin = pr
+		defer func() { os.Stdin = stdin }()
+
+		go func() {
+			pw.Write([]byte("abc\ndef\n"))
+			pw.Close()
+		}()
+
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ParallelScan = true
+
+		chunks := make(chan string, 128)
+		err = r.ReadStdin(func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.NoError(err)
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+	})
+}
+
+func BenchmarkReadFile(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "input.txt")
+	os.WriteFile(path, bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog\n"), 1<<16), 0644)
+
+	for _, size := range []int{0, 1 << 20} {
+		b.Run(fmt.Sprintf("ReadBufferSize=%d", size), func(b *testing.B) {
+			r := NewParallelReader()
+			r.ChunkSize = 1 << 10
+			r.ReadBufferSize = size
+
+			for i := 0; i < b.N; i++ {
+				r.ReadFile(path, func(chunk []byte) {})
+			}
+		})
+	}
+}
+
+func TestReadFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.txt")
+	missing := filepath.Join(dir, "missing.txt")
+	alsoMissing := filepath.Join(dir, "also_missing.txt")
+	os.WriteFile(good, []byte("abc\ndef\n"), 0644)
+
+	t.Run("when all files can be read", func(t *testing.T) {
+		r := NewParallelReader()
+
+		chunks := make(chan string, 128)
+		err := r.ReadFiles([]string{good, good}, func(path string, chunk []byte) {
+			chunks <- path + ":" + string(chunk)
+		})
+		close(chunks)
+
+		assert.NoError(err)
+		assert.Equal([]string{good + ":abc\ndef\n", good + ":abc\ndef\n"}, drain(chunks))
+	})
+
+	t.Run("when some files fail", func(t *testing.T) {
+		r := NewParallelReader()
+
+		chunks := make(chan string, 128)
+		err := r.ReadFiles([]string{missing, good, alsoMissing}, func(path string, chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.Equal([]string{"abc\ndef\n"}, drain(chunks))
+
+		var multi *MultiError
+		if assert.True(errors.As(err, &multi)) {
+			assert.Equal([]string{missing, alsoMissing}, multi.Paths())
+		}
+		assert.ErrorIs(err, os.ErrNotExist)
+	})
+}
@@ -0,0 +1,135 @@
+package rip
+
+import "bytes"
+
+// The boundary searches below compare bytes exactly, or ignoring the case of
+// ASCII letters if CaseInsensitiveBoundary is set. Case is folded one byte at
+// a time as data is compared, rather than lowercasing a copy of it.
+
+// index returns the index of the first match of sep in data, or -1.
+func (r *ParallelReader) index(data, sep []byte) int {
+	if !r.CaseInsensitiveBoundary {
+		return bytes.Index(data, sep)
+	}
+	if len(sep) == 0 {
+		return 0
+	}
+
+	for i := 0; i+len(sep) <= len(data); i++ {
+		// Skip ahead to the next byte that could begin a match.
+		j := indexByteFold(data[i:len(data)-len(sep)+1], sep[0])
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if equalFold(data[i:i+len(sep)], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastIndex returns the index of the last match of sep in data, or -1.
+func (r *ParallelReader) lastIndex(data, sep []byte) int {
+	if !r.CaseInsensitiveBoundary {
+		return bytes.LastIndex(data, sep)
+	}
+	if len(sep) == 0 {
+		return len(data)
+	}
+
+	for i := len(data) - len(sep); i >= 0; i-- {
+		if equalFold(data[i:i+len(sep)], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
+// contains reports whether sep appears in data.
+func (r *ParallelReader) contains(data, sep []byte) bool {
+	return r.index(data, sep) > -1
+}
+
+// count returns the number of non-overlapping matches of sep in data.
+func (r *ParallelReader) count(data, sep []byte) int {
+	if !r.CaseInsensitiveBoundary {
+		return bytes.Count(data, sep)
+	}
+
+	n := 0
+	for {
+		idx := r.index(data, sep)
+		if idx < 0 {
+			return n
+		}
+		n++
+		data = data[idx+len(sep):]
+	}
+}
+
+// hasPrefix reports whether data begins with prefix.
+func (r *ParallelReader) hasPrefix(data, prefix []byte) bool {
+	return len(data) >= len(prefix) && r.equal(data[:len(prefix)], prefix)
+}
+
+// hasSuffix reports whether data ends with suffix.
+func (r *ParallelReader) hasSuffix(data, suffix []byte) bool {
+	return len(data) >= len(suffix) && r.equal(data[len(data)-len(suffix):], suffix)
+}
+
+// equal reports whether a and b match.
+func (r *ParallelReader) equal(a, b []byte) bool {
+	if !r.CaseInsensitiveBoundary {
+		return bytes.Equal(a, b)
+	}
+	return equalFold(a, b)
+}
+
+// equalFold reports whether a and b are equal, ignoring the case of ASCII
+// letters.
+func equalFold(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if lowerASCII(a[i]) != lowerASCII(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexByteFold returns the index of the first byte in data that's c, in
+// either case if it's an ASCII letter, or -1.
+func indexByteFold(data []byte, c byte) int {
+	lower, upper := lowerASCII(c), upperASCII(c)
+	idx := bytes.IndexByte(data, lower)
+	if lower == upper {
+		return idx
+	}
+
+	// Only search for the other case before the first match of this one.
+	limit := len(data)
+	if idx > -1 {
+		limit = idx
+	}
+	if other := bytes.IndexByte(data[:limit], upper); other > -1 {
+		return other
+	}
+	return idx
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func upperASCII(c byte) byte {
+	if 'a' <= c && c <= 'z' {
+		return c - ('a' - 'A')
+	}
+	return c
+}
@@ -0,0 +1,80 @@
+package rip
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaseInsensitiveBoundary(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("searches ignoring case", func(t *testing.T) {
+		r := NewParallelReader()
+		r.CaseInsensitiveBoundary = true
+
+		data := []byte("a<Foo>b<FOO>c<fOo")
+		assert.Equal(1, r.index(data, []byte("<foo>")))
+		assert.Equal(7, r.lastIndex(data, []byte("<foo>")))
+		assert.Equal(2, r.count(data, []byte("<foo>")))
+		assert.Equal(-1, r.index(data, []byte("<bar>")))
+		assert.True(r.hasSuffix(data, []byte("<FOO")))
+		assert.True(r.hasPrefix(data, []byte("A<f")))
+
+		// Only letters are folded.
+		assert.Equal(-1, r.index([]byte("a{b"), []byte("[")))
+
+		allocs := testing.AllocsPerRun(10, func() {
+			r.index(data, []byte("<foo>"))
+		})
+		assert.Zero(allocs)
+	})
+
+	t.Run("with Read", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ChunkBoundary = "</item>"
+		r.CaseInsensitiveBoundary = true
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("one</item>TWO</ITEM>three</Item>"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		// Chunks are delivered as read.
+		assert.ElementsMatch([]string{"one</item>", "TWO</ITEM>", "three</Item>"}, drain(chunks))
+	})
+
+	t.Run("with ReadRegions", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 64
+		r.ChunkBoundaryStart = "<foo>"
+		r.ChunkBoundary = "</foo>"
+		r.CaseInsensitiveBoundary = true
+
+		chunks := make(chan string, 128)
+		r.ReadRegions(strings.NewReader("a <FOO>b</Foo> c"), func(chunk []byte, inside bool) {
+			chunks <- fmt.Sprintf("%t:%s", inside, chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"false:a ", "true:<FOO>b</Foo>", "false: c"}, drain(chunks))
+	})
+
+	t.Run("is off by default", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ChunkBoundary = "</item>"
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("one</item>TWO</ITEM>"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"one</item>", "TWO</ITEM>"}, drain(chunks))
+	})
+}
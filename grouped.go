@@ -0,0 +1,69 @@
+package rip
+
+import (
+	"io"
+	"sort"
+	"sync"
+)
+
+// A copy of a chunk's bytes, remembering where it appeared in the stream.
+type indexedBytes struct {
+	index int
+	bytes []byte
+}
+
+// ReadGrouped reads stream like Read and groups its chunks by the key keyFn
+// returns for each of them, then calls work once per group from a pool of
+// goroutines. Within a group, chunks are passed in the order they appeared in
+// the input, but groups themselves could be processed in any order.
+//
+// keyFn is called in parallel from the worker goroutines. Unlike Read, the
+// chunks passed to work are copies that may be safely retained.
+//
+// Since no group is complete until the whole stream has been read, every chunk
+// is held in memory until the end: memory use is at least the size of the
+// input. Only use this on bounded datasets that comfortably fit in memory.
+func (r *ParallelReader) ReadGrouped(stream io.Reader, keyFn func(chunk []byte) string, work func(key string, chunks [][]byte)) {
+	var mu sync.Mutex
+	groups := make(map[string][]indexedBytes)
+
+	err := r.run(stream, nil, func(c *chunk) {
+		chunk := c.ReadableBytes()
+		key := keyFn(chunk)
+		copied := append([]byte(nil), chunk...)
+
+		mu.Lock()
+		groups[key] = append(groups[key], indexedBytes{index: c.index, bytes: copied})
+		mu.Unlock()
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	keys := make(chan string, len(groups))
+	for key := range groups {
+		keys <- key
+	}
+	close(keys)
+
+	var wg sync.WaitGroup
+	wg.Add(r.Concurrency)
+	for i := 0; i < r.Concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for key := range keys {
+				group := groups[key]
+				sort.Slice(group, func(i, j int) bool {
+					return group[i].index < group[j].index
+				})
+
+				chunks := make([][]byte, len(group))
+				for i, c := range group {
+					chunks[i] = c.bytes
+				}
+				work(key, chunks)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,35 @@
+package rip
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadGrouped(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewParallelReader()
+	r.ChunkSize = 16
+
+	input := "2021-01-01 a\n2021-01-02 b\n2021-01-01 c\n2021-01-02 d\n2021-01-01 e\n"
+
+	var mu sync.Mutex
+	groups := make(map[string][]string)
+	r.ReadGrouped(strings.NewReader(input), func(chunk []byte) string {
+		return string(chunk[:10])
+	}, func(key string, chunks [][]byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, chunk := range chunks {
+			groups[key] = append(groups[key], string(chunk))
+		}
+	})
+
+	assert.Equal(map[string][]string{
+		"2021-01-01": {"2021-01-01 a\n", "2021-01-01 c\n", "2021-01-01 e\n"},
+		"2021-01-02": {"2021-01-02 b\n", "2021-01-02 d\n"},
+	}, groups)
+}
@@ -0,0 +1,58 @@
+package rip
+
+import (
+	"io"
+	"slices"
+	"sync"
+)
+
+// BuildIndex returns the offset in source of the start of every record in its
+// first size bytes, sorted in ascending order, for building a sidecar index
+// that allows random access to records by number: record i spans from
+// offsets[i] to offsets[i+1], or to size for the last record. The source is
+// scanned in parallel sections like ParallelScan.
+//
+// Records end with ChunkBoundary, matched regardless of case if
+// CaseInsensitiveBoundary is set; ChunkBoundaries, ChunkBoundaryStart, Filter,
+// sampling and NormalizeLineEndings are ignored, but r isn't modified. With
+// RequireBoundary, a final record without a ChunkBoundary isn't indexed.
+func (r *ParallelReader) BuildIndex(source io.ReaderAt, size int64) ([]int64, error) {
+	c := r.with([]Option{func(c *ParallelReader) {
+		c.ChunkBoundaries = nil
+		c.ChunkBoundaryStart = ""
+		c.FirstBoundary = ""
+		c.Filter = nil
+		c.SampleEvery = 0
+		c.SampleRate = 0
+		c.NormalizeLineEndings = false
+	}})
+
+	boundary := []byte(c.ChunkBoundary)
+	var mu sync.Mutex
+	var offsets []int64
+	err := c.readParallel(source, 0, size, func(ch *chunk) {
+		var local []int64
+		offset := ch.offset
+		// Split like Records, but finding boundaries with the same case
+		// sensitivity as the sections were split with.
+		for data := ch.ReadableBytes(); len(data) > 0; {
+			end := len(data)
+			if idx := c.index(data, boundary); idx > -1 && len(boundary) > 0 {
+				end = idx + len(boundary)
+			}
+			local = append(local, offset)
+			offset += int64(end)
+			data = data[end:]
+		}
+
+		mu.Lock()
+		offsets = append(offsets, local...)
+		mu.Unlock()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(offsets)
+	return offsets, nil
+}
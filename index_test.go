@@ -0,0 +1,60 @@
+package rip
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("returns the offset of every record", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 3
+		r.ChunkSize = 8
+
+		input := "a\nbb\nccc\ndddd\neeeee\nffffff\ng"
+		offsets, err := r.BuildIndex(strings.NewReader(input), int64(len(input)))
+
+		assert.NoError(err)
+		assert.Equal([]int64{0, 2, 5, 9, 14, 20, 27}, offsets)
+	})
+
+	t.Run("when a record is shorter than Concurrency bytes", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 3
+
+		offsets, err := r.BuildIndex(strings.NewReader("ab\n"), 3)
+
+		assert.NoError(err)
+		assert.Equal([]int64{0}, offsets)
+	})
+
+	t.Run("with CaseInsensitiveBoundary", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 1
+		r.ChunkSize = 64
+		r.ChunkBoundary = "end"
+		r.CaseInsensitiveBoundary = true
+
+		input := "aaEndbbENDccend"
+		offsets, err := r.BuildIndex(strings.NewReader(input), int64(len(input)))
+
+		assert.NoError(err)
+		assert.Equal([]int64{0, 5, 10}, offsets)
+	})
+
+	t.Run("with RequireBoundary", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.RequireBoundary = true
+
+		input := "a\nbb\nccc"
+		offsets, err := r.BuildIndex(strings.NewReader(input), int64(len(input)))
+
+		assert.NoError(err)
+		assert.Equal([]int64{0, 2}, offsets)
+	})
+}
@@ -0,0 +1,81 @@
+package rip
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// JSONLineError is returned by ReadJSONLines when a line can't be unmarshaled.
+type JSONLineError struct {
+	// The 1-based line number in the stream.
+	Line int
+	Err  error
+}
+
+func (e *JSONLineError) Error() string {
+	return fmt.Sprintf("rip: invalid JSON on line %d: %v", e.Line, e.Err)
+}
+
+func (e *JSONLineError) Unwrap() error {
+	return e.Err
+}
+
+// ReadJSONLines reads newline-delimited JSON from stream using r, unmarshaling
+// each line into a T and passing it to work. Unmarshaling happens on the worker
+// goroutines, so values arrive in no particular order. Blank lines are
+// skipped. r's boundary settings are ignored in favour of "\n", but r itself
+// isn't modified.
+//
+// The run stops at the first line that fails to unmarshal, returning a
+// *JSONLineError, or at the first error returned by work, which is returned as
+// is; work can return Stop to end the run early without an error. Lines must
+// fit within MaxChunkSize, or 16 times ChunkSize if it isn't set; a longer one
+// fails the run with bufio.ErrTooLong.
+//
+// This is a function rather than a method because Go methods can't have type
+// parameters.
+func ReadJSONLines[T any](r *ParallelReader, stream io.Reader, work func(value T) error) error {
+	c := r.with([]Option{func(c *ParallelReader) {
+		c.ChunkBoundary = "\n"
+		c.ChunkBoundaries = nil
+		c.ChunkBoundaryStart = ""
+	}})
+	c.countLines = true
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	scanErr := c.run(stream, ctx.Done(), func(ch *chunk) {
+		line := ch.startLine
+		for record := range Records(ch.ReadableBytes(), "\n") {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if len(bytes.TrimSpace(record)) > 0 {
+				var value T
+				if err := json.Unmarshal(record, &value); err != nil {
+					cancel(&JSONLineError{Line: line, Err: err})
+					return
+				}
+				if err := work(value); err != nil {
+					cancel(err)
+					return
+				}
+			}
+			line++
+		}
+	})
+
+	if err := context.Cause(ctx); err != nil {
+		if errors.Is(err, Stop) {
+			return nil
+		}
+		return err
+	}
+	return scanErr
+}
@@ -0,0 +1,62 @@
+package rip
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadJSONLines(t *testing.T) {
+	assert := assert.New(t)
+
+	type record struct {
+		Name string `json:"name"`
+	}
+
+	t.Run("unmarshals each line", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 32
+		r.ChunkBoundary = ";"
+
+		var mu sync.Mutex
+		var names []string
+		err := ReadJSONLines(r, strings.NewReader("{\"name\":\"a\"}\n\n{\"name\":\"b\"}\n{\"name\":\"c\"}"), func(v record) error {
+			mu.Lock()
+			defer mu.Unlock()
+			names = append(names, v.Name)
+			return nil
+		})
+
+		assert.NoError(err)
+		assert.ElementsMatch([]string{"a", "b", "c"}, names)
+		assert.Equal(";", r.ChunkBoundary)
+	})
+
+	t.Run("reports the line of invalid JSON", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 32
+
+		err := ReadJSONLines(r, strings.NewReader("{\"name\":\"a\"}\n{\"name\":\"b\"}\n{oops}\n"), func(v record) error {
+			return nil
+		})
+
+		var lineErr *JSONLineError
+		if assert.True(errors.As(err, &lineErr)) {
+			assert.Equal(3, lineErr.Line)
+		}
+	})
+
+	t.Run("returns callback errors", func(t *testing.T) {
+		r := NewParallelReader()
+
+		errBoom := errors.New("boom")
+		err := ReadJSONLines(r, strings.NewReader("{\"name\":\"a\"}\n"), func(v record) error {
+			return errBoom
+		})
+
+		assert.ErrorIs(err, errBoom)
+	})
+}
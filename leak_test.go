@@ -0,0 +1,177 @@
+package rip
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertNoLeaks runs fn, then checks that every goroutine it started has
+// exited. Goroutines take a moment to exit after signalling that they're done,
+// so, like goleak, it allows them a little time.
+func assertNoLeaks(t *testing.T, fn func()) {
+	t.Helper()
+
+	before := runtime.NumGoroutine()
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		fn()
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the read to return")
+	}
+
+	// assert.Eventually can't be used here, since it checks its condition on a
+	// goroutine of its own.
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before; {
+		if time.Now().After(deadline) {
+			t.Errorf("%d goroutines left running", runtime.NumGoroutine()-before)
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGoroutineLeaks(t *testing.T) {
+	input := strings.Repeat("abc\n", 1000)
+
+	t.Run("after a complete read", func(t *testing.T) {
+		assertNoLeaks(t, func() {
+			r := NewParallelReader()
+			r.ChunkSize = 16
+			r.Read(strings.NewReader(input), func(chunk []byte) {})
+		})
+	})
+
+	t.Run("after a stream error", func(t *testing.T) {
+		assertNoLeaks(t, func() {
+			r := NewParallelReader()
+			r.ChunkSize = 16
+			r.RecoverPanics = true
+			r.Read(io.MultiReader(strings.NewReader(input), iotest.ErrReader(errTest)), func(chunk []byte) {})
+		})
+	})
+
+	t.Run("after cancellation", func(t *testing.T) {
+		assertNoLeaks(t, func() {
+			r := NewParallelReader()
+			r.ChunkSize = 16
+
+			ctx, cancel := context.WithCancel(context.Background())
+			var chunks int64
+			r.ReadContextWork(ctx, strings.NewReader(input), func(ctx context.Context, chunk []byte) error {
+				if atomic.AddInt64(&chunks, 1) == 10 {
+					cancel()
+				}
+				return nil
+			})
+		})
+	})
+
+	t.Run("after a timeout", func(t *testing.T) {
+		assertNoLeaks(t, func() {
+			r := NewParallelReader()
+			r.ChunkSize = 16
+			r.ReadWithTimeout(time.Millisecond, strings.NewReader(input), func(chunk []byte) {
+				time.Sleep(time.Millisecond)
+			})
+		})
+	})
+
+	t.Run("after a recovered panic", func(t *testing.T) {
+		assertNoLeaks(t, func() {
+			r := NewParallelReader()
+			r.ChunkSize = 16
+			r.RecoverPanics = true
+			r.Read(strings.NewReader(input), func(chunk []byte) {
+				panic("boom")
+			})
+		})
+	})
+
+	t.Run("after a recovered panic in Transform", func(t *testing.T) {
+		assertNoLeaks(t, func() {
+			r := NewParallelReader()
+			r.Concurrency = 4
+			r.ChunkSize = 4
+			r.RecoverPanics = true
+
+			// The first chunk panics only once the other workers have got as far
+			// ahead of it as the reorder window allows.
+			var calls int64
+			err := r.Transform(strings.NewReader(input), io.Discard, func(chunk []byte) []byte {
+				if atomic.AddInt64(&calls, 1) == 1 {
+					time.Sleep(50 * time.Millisecond)
+					panic("boom")
+				}
+				return chunk
+			})
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("after a write error in Transform", func(t *testing.T) {
+		assertNoLeaks(t, func() {
+			r := NewParallelReader()
+			r.Concurrency = 4
+			r.ChunkSize = 4
+
+			// Fail once some output has been written, so the workers still waiting
+			// to deliver results have to be released partway through.
+			w := &failAfterWriter{n: 10}
+			err := r.Transform(strings.NewReader(input), w, func(chunk []byte) []byte {
+				return chunk
+			})
+			assert.ErrorIs(t, err, errWrite)
+		})
+	})
+
+	t.Run("with Prefetch, after cancellation", func(t *testing.T) {
+		assertNoLeaks(t, func() {
+			r := NewParallelReader()
+			r.ChunkSize = 16
+			r.Prefetch = 8
+
+			done := make(chan struct{})
+			var chunks int64
+			r.runFixed(strings.NewReader(input), done, func(c *chunk) {
+				if atomic.AddInt64(&chunks, 1) == 10 {
+					close(done)
+				}
+			})
+		})
+	})
+
+	t.Run("with Priority", func(t *testing.T) {
+		assertNoLeaks(t, func() {
+			r := NewParallelReader()
+			r.ChunkSize = 16
+			r.Priority = func(chunk []byte) int { return len(chunk) }
+			r.Read(strings.NewReader(input), func(chunk []byte) {})
+		})
+	})
+}
+
+// failAfterWriter fails every write after the first n.
+type failAfterWriter struct {
+	n int
+}
+
+func (w *failAfterWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errWrite
+	}
+	w.n--
+	return len(p), nil
+}
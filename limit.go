@@ -0,0 +1,96 @@
+package rip
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ErrMaxBytes is returned when FailOnMaxBytes is set and a stream is longer
+// than MaxBytes.
+var ErrMaxBytes = errors.New("rip: stream exceeds MaxBytes")
+
+// ErrMaxLineLength is returned when a record is longer than MaxLineLength.
+var ErrMaxLineLength = errors.New("rip: record exceeds MaxLineLength")
+
+// limiting wraps stream to enforce MaxBytes, if it's set, keeping hold of the
+// wrapper so the scanner can tell whether the limit cut the stream short.
+func (r *ParallelReader) limiting(stream io.Reader) io.Reader {
+	r.limit = nil
+	if r.MaxBytes <= 0 {
+		return stream
+	}
+
+	r.limit = &limitReader{r: stream, n: r.MaxBytes}
+	return r.limit
+}
+
+// truncated reports whether the current read was cut short by MaxBytes. It's
+// only accurate once the wrapped stream has returned io.EOF.
+func (r *ParallelReader) truncated() bool {
+	return r.limit != nil && r.limit.exceeded
+}
+
+// limitReader is like io.LimitedReader, but once n bytes have been read it
+// checks whether r had any more, so that a stream of exactly n bytes can be
+// told apart from a longer one.
+type limitReader struct {
+	r        io.Reader
+	n        int64
+	probed   bool
+	exceeded bool
+
+	// The byte read from r to check whether it had more, and the partial record
+	// cut off by the limit, which make up the start of Remainder.
+	probe []byte
+	cut   []byte
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		if !l.probed {
+			l.probed = true
+			var b [1]byte
+			n, err := io.ReadFull(l.r, b[:])
+			l.exceeded = n > 0
+			l.probe = b[:n]
+			if err != nil && err != io.EOF {
+				return 0, err
+			}
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// rest returns a reader over the part of the stream after the last byte
+// delivered, once the limit has cut it short.
+func (l *limitReader) rest() io.Reader {
+	unread := make([]byte, 0, len(l.cut)+len(l.probe))
+	unread = append(unread, l.cut...)
+	unread = append(unread, l.probe...)
+	return io.MultiReader(bytes.NewReader(unread), l.r)
+}
+
+// recordTooLong reports whether any of the records in data is longer than
+// MaxLineLength, not counting its boundary. The last record need not have a
+// boundary.
+func (r *ParallelReader) recordTooLong(data []byte, atEOF bool) bool {
+	for len(data) > r.MaxLineLength {
+		end := r.firstBoundaryEnd(data, atEOF)
+		if end < 0 {
+			return true
+		}
+		if end-len(r.trailingBoundary(data[:end])) > r.MaxLineLength {
+			return true
+		}
+		data = data[end:]
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package rip
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("stops at the last boundary within the limit", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.MaxBytes = 10
+
+		var dropped string
+		r.OnDropped = func(data []byte) {
+			dropped = string(data)
+		}
+
+		chunks := make(chan string, 128)
+		result := r.Read(strings.NewReader("abc\ndef\nghi\njkl\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+		assert.Equal("gh", dropped)
+		assert.False(result.Completed)
+	})
+
+	t.Run("when the stream is exactly MaxBytes long", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.MaxBytes = 8
+		r.FailOnMaxBytes = true
+
+		chunks := make(chan string, 128)
+		result := r.Read(strings.NewReader("abc\ndef\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+		assert.True(result.Completed)
+	})
+
+	t.Run("with FailOnMaxBytes", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.MaxBytes = 8
+		r.FailOnMaxBytes = true
+
+		assert.PanicsWithError(ErrMaxBytes.Error(), func() {
+			r.Read(strings.NewReader("abc\ndef\nghi\n"), func(chunk []byte) {})
+		})
+	})
+
+	t.Run("with ReadFixed", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.MaxBytes = 6
+
+		chunks := make(chan string, 128)
+		r.ReadFixed(strings.NewReader("abcdefghij"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abcd", "ef"}, drain(chunks))
+	})
+}
+
+func TestMaxLineLength(t *testing.T) {
+	assert := assert.New(t)
+
+	newReader := func() *ParallelReader {
+		r := NewParallelReader()
+		r.ChunkSize = 64
+		r.MaxLineLength = 8
+		r.RecoverPanics = true
+		return r
+	}
+
+	t.Run("with records within the limit", func(t *testing.T) {
+		chunks := make(chan string, 128)
+		result := newReader().Read(strings.NewReader("12345678\nabc\n12345678"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.NoError(result.Err)
+		assert.Equal("12345678\nabc\n12345678", strings.Join(drain(chunks), ""))
+	})
+
+	t.Run("with a long record among short ones", func(t *testing.T) {
+		result := newReader().Read(strings.NewReader("abc\n123456789\ndef\n"), func(chunk []byte) {})
+		assert.ErrorIs(result.Err, ErrMaxLineLength)
+	})
+
+	t.Run("with a long final record", func(t *testing.T) {
+		result := newReader().Read(strings.NewReader("abc\n123456789"), func(chunk []byte) {})
+		assert.ErrorIs(result.Err, ErrMaxLineLength)
+	})
+
+	t.Run("stops buffering an endless record", func(t *testing.T) {
+		r := newReader()
+
+		// Far more than MaxChunkSize would allow, so the read would fail with
+		// bufio.ErrTooLong if the record were buffered that far.
+		stream := &countingReader{r: oneByteReader(strings.Repeat("a", 1<<20))}
+		result := r.Read(stream, func(chunk []byte) {})
+
+		assert.ErrorIs(result.Err, ErrMaxLineLength)
+		assert.Less(stream.n, int64(r.ChunkSize*2))
+	})
+
+	t.Run("with records longer than ChunkSize", func(t *testing.T) {
+		r := newReader()
+		r.ChunkSize = 4
+		r.MaxLineLength = 16
+
+		chunks := make(chan string, 128)
+		result := r.Read(oneByteReader("0123456789\nab\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.NoError(result.Err)
+		assert.ElementsMatch([]string{"0123456789\n", "ab\n"}, drain(chunks))
+	})
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
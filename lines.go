@@ -0,0 +1,99 @@
+package rip
+
+import (
+	"bytes"
+	"io"
+	"unsafe"
+)
+
+// LineEndings are the line endings LineMode splits on: Unix "\n", Windows
+// "\r\n", and the lone "\r" of classic Mac OS.
+var LineEndings = []string{"\r\n", "\n", "\r"}
+
+// LineMode is a preset for reading text files with any mix of line endings. It
+// sets ChunkBoundaries to LineEndings and enables NormalizeLineEndings, so each
+// chunk is one or more whole lines joined by "\n", with no line ending at the
+// end. It can be passed to ReadWith, or applied with NewLineReader.
+var LineMode Option = func(r *ParallelReader) {
+	r.ChunkBoundaries = append([]string(nil), LineEndings...)
+	r.NormalizeLineEndings = true
+}
+
+// NewLineReader returns a new ParallelReader with the defaults of
+// NewParallelReader and LineMode applied.
+func NewLineReader() *ParallelReader {
+	r := NewParallelReader()
+	LineMode(r)
+	return r
+}
+
+// ProcessLines reads stream with LineMode applied, whatever r's own boundary
+// settings are, and calls work once for every line, with its line ending
+// removed, including blank lines. Lines are delivered from a pool of
+// goroutines like Read, in any order; the lines of each chunk are passed to
+// work one after another on the worker that received it. As with Read, a line
+// must not be used after work returns. Like ReadWith, it leaves r unchanged,
+// so Stats on r don't reflect it; use the returned Result instead.
+func (r *ParallelReader) ProcessLines(stream io.Reader, work func(line []byte)) Result {
+	return r.ReadWith(stream, func(chunk []byte) {
+		for {
+			line, rest, found := bytes.Cut(chunk, newline)
+			work(line)
+			if !found {
+				return
+			}
+			chunk = rest
+		}
+	}, LineMode)
+}
+
+// ProcessLinesString is ProcessLines, but passes each line to work as a string
+// that shares its memory with the chunk, like ReadStrings, so the same care is
+// needed not to keep it after work returns.
+func (r *ParallelReader) ProcessLinesString(stream io.Reader, work func(line string)) Result {
+	return r.ProcessLines(stream, func(line []byte) {
+		work(unsafe.String(unsafe.SliceData(line), len(line)))
+	})
+}
+
+var newline = []byte("\n")
+
+// normalizingLineEndings wraps fn so that, if NormalizeLineEndings is set, the
+// line endings in each chunk are rewritten before fn sees it. This happens on
+// the worker goroutines. Rewriting only ever shortens a chunk, so it's done in
+// place in the buffer, but fn is passed a copy of the chunk since the scanner
+// may still be reading the original's size for Stats.
+func (r *ParallelReader) normalizingLineEndings(fn func(c *chunk)) func(c *chunk) {
+	if !r.NormalizeLineEndings {
+		return fn
+	}
+
+	return func(c *chunk) {
+		normalized := *c
+		normalized.readableSize = normalizeLineEndings(c.ReadableBytes())
+		fn(&normalized)
+	}
+}
+
+// normalizeLineEndings rewrites each "\r\n" and lone "\r" in data to "\n", and
+// removes the line ending at the end of data if there is one. It returns the
+// new length of data.
+func normalizeLineEndings(data []byte) int {
+	n := 0
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		if b == '\r' {
+			b = '\n'
+			if i+1 < len(data) && data[i+1] == '\n' {
+				i++
+			}
+		}
+		data[n] = b
+		n++
+	}
+
+	if n > 0 && data[n-1] == '\n' {
+		n--
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+package rip
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLineReader(t *testing.T) {
+	assert := assert.New(t)
+
+	input := "unix\nwindows\r\nmac\rmore unix\n\nlast"
+
+	for _, size := range []int{12, 1 << 10} {
+		r := NewLineReader()
+		r.ChunkSize = size
+
+		lines := make(chan string, 128)
+		r.Read(strings.NewReader(input), func(chunk []byte) {
+			for _, line := range bytes.Split(chunk, []byte("\n")) {
+				lines <- string(line)
+			}
+		})
+		close(lines)
+
+		assert.ElementsMatch([]string{"unix", "windows", "mac", "more unix", "", "last"}, drain(lines), "ChunkSize=%d", size)
+	}
+}
+
+func TestProcessLines(t *testing.T) {
+	assert := assert.New(t)
+
+	input := "unix\nwindows\r\nmac\rmore unix\n\n\nlast"
+	expected := []string{"unix", "windows", "mac", "more unix", "", "", "last"}
+
+	for _, size := range []int{1, 12, 1 << 10} {
+		r := NewParallelReader()
+		r.ChunkSize = size
+
+		lines := make(chan string, 128)
+		result := r.ProcessLines(strings.NewReader(input), func(line []byte) {
+			lines <- string(line)
+		})
+		close(lines)
+
+		assert.True(result.Completed)
+		assert.ElementsMatch(expected, drain(lines), "ChunkSize=%d", size)
+	}
+
+	t.Run("leaves the reader unchanged", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkBoundary = "|"
+
+		r.ProcessLines(strings.NewReader(input), func(line []byte) {})
+
+		assert.Equal("|", r.ChunkBoundary)
+		assert.False(r.NormalizeLineEndings)
+	})
+
+	t.Run("ProcessLinesString", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 12
+
+		lines := make(chan string, 128)
+		r.ProcessLinesString(strings.NewReader(input), func(line string) {
+			lines <- strings.Clone(line)
+		})
+		close(lines)
+
+		assert.ElementsMatch(expected, drain(lines))
+	})
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+	assert := assert.New(t)
+
+	for input, expected := range map[string]string{
+		"":            "",
+		"a":           "a",
+		"a\n":         "a",
+		"a\r\n":       "a",
+		"a\r":         "a",
+		"a\r\nb\rc\n": "a\nb\nc",
+		"\r\r\n\n":    "\n\n",
+	} {
+		data := []byte(input)
+		assert.Equal(expected, string(data[:normalizeLineEndings(data)]), "%q", input)
+	}
+}
@@ -0,0 +1,81 @@
+package rip
+
+import "container/heap"
+
+// MergeOrdered merges sources, each of which must already be in order by
+// less, into a single channel in order by less, for combining the ordered
+// results of several reads, like one PipeOrdered per shard of a sorted data
+// set, into one stream as in an external merge sort. Values that are equal by
+// less are sent in the order of their sources, so the merge is stable.
+//
+// Merging starts in a goroutine of its own, which waits for a value from every
+// source before sending the first one, as it can't tell which is least until
+// it has. Only one value per source is held at a time. The returned channel is
+// closed once every source has been closed and drained; until then it needs to
+// be received from, or the merge, and whatever is sending to the sources, will
+// block.
+func MergeOrdered[T any](sources []<-chan T, less func(a, b T) bool) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		queue := mergeQueue[T]{less: less}
+		for i, source := range sources {
+			if value, ok := <-source; ok {
+				queue.heads = append(queue.heads, mergeHead[T]{value: value, source: i})
+			}
+		}
+		heap.Init(&queue)
+
+		for queue.Len() > 0 {
+			head := queue.heads[0]
+			out <- head.value
+
+			if value, ok := <-sources[head.source]; ok {
+				queue.heads[0].value = value
+				heap.Fix(&queue, 0)
+			} else {
+				heap.Pop(&queue)
+			}
+		}
+	}()
+
+	return out
+}
+
+// mergeHead is the next value from one of MergeOrdered's sources.
+type mergeHead[T any] struct {
+	value  T
+	source int
+}
+
+// mergeQueue is a heap of the next value from each of MergeOrdered's sources,
+// implementing heap.Interface, with the least first, breaking ties by source.
+type mergeQueue[T any] struct {
+	heads []mergeHead[T]
+	less  func(a, b T) bool
+}
+
+func (q mergeQueue[T]) Len() int { return len(q.heads) }
+
+func (q mergeQueue[T]) Less(i, j int) bool {
+	a, b := q.heads[i], q.heads[j]
+	if q.less(a.value, b.value) {
+		return true
+	}
+	if q.less(b.value, a.value) {
+		return false
+	}
+	return a.source < b.source
+}
+
+func (q mergeQueue[T]) Swap(i, j int) { q.heads[i], q.heads[j] = q.heads[j], q.heads[i] }
+
+func (q *mergeQueue[T]) Push(x any) { q.heads = append(q.heads, x.(mergeHead[T])) }
+
+func (q *mergeQueue[T]) Pop() any {
+	last := q.heads[len(q.heads)-1]
+	q.heads = q.heads[:len(q.heads)-1]
+	return last
+}
@@ -0,0 +1,93 @@
+package rip
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeOrdered(t *testing.T) {
+	assert := assert.New(t)
+
+	source := func(values ...int) <-chan int {
+		ch := make(chan int)
+		go func() {
+			defer close(ch)
+			for _, v := range values {
+				ch <- v
+			}
+		}()
+		return ch
+	}
+	drain := func(ch <-chan int) []int {
+		var values []int
+		for v := range ch {
+			values = append(values, v)
+		}
+		return values
+	}
+	less := func(a, b int) bool { return a < b }
+
+	t.Run("merges sorted sources", func(t *testing.T) {
+		merged := MergeOrdered([]<-chan int{source(1, 4, 7), source(2, 5, 8, 9), source(3, 6)}, less)
+		assert.Equal([]int{1, 2, 3, 4, 5, 6, 7, 8, 9}, drain(merged))
+	})
+
+	t.Run("with empty sources", func(t *testing.T) {
+		merged := MergeOrdered([]<-chan int{source(), source(2, 3), source()}, less)
+		assert.Equal([]int{2, 3}, drain(merged))
+
+		assert.Empty(drain(MergeOrdered(nil, less)))
+	})
+
+	t.Run("sends equal values in the order of their sources", func(t *testing.T) {
+		type entry struct {
+			key    int
+			source string
+		}
+		entries := func(name string, keys ...int) <-chan entry {
+			ch := make(chan entry, len(keys))
+			for _, key := range keys {
+				ch <- entry{key, name}
+			}
+			close(ch)
+			return ch
+		}
+
+		merged := MergeOrdered([]<-chan entry{entries("a", 1, 2), entries("b", 1, 2)}, func(x, y entry) bool {
+			return x.key < y.key
+		})
+
+		var got []string
+		for e := range merged {
+			got = append(got, e.source)
+		}
+		assert.Equal([]string{"a", "b", "a", "b"}, got)
+	})
+
+	t.Run("with PipeOrdered from several readers", func(t *testing.T) {
+		shards := []string{"01\n04\n05\n", "02\n03\n08\n", "06\n07\n09\n"}
+
+		var sources []<-chan string
+		for _, shard := range shards {
+			out := make(chan string)
+			sources = append(sources, out)
+
+			r := NewParallelReader()
+			r.ChunkSize = 3
+			go func() {
+				defer close(out)
+				PipeOrdered(r, strings.NewReader(shard), func(chunk []byte) string {
+					return strings.TrimSuffix(string(chunk), "\n")
+				}, out)
+			}()
+		}
+
+		var merged []string
+		for line := range MergeOrdered(sources, func(a, b string) bool { return a < b }) {
+			merged = append(merged, line)
+		}
+		assert.Equal([]string{"01", "02", "03", "04", "05", "06", "07", "08", "09"}, merged)
+	})
+}
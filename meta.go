@@ -0,0 +1,84 @@
+package rip
+
+import "io"
+
+// ChunkInfo is a chunk passed to a ReadMeta callback, along with what's known
+// about it.
+type ChunkInfo struct {
+	// The chunk itself, which, as with Read, must not be used after the
+	// callback returns.
+	Bytes []byte
+	// The position of the chunk among all those in the stream, counting from 0.
+	Index int
+	// The offset in the stream at which the chunk starts.
+	Offset int64
+	// The 1-based line number of the first line in the chunk, as passed by
+	// ReadLineNumbered. It's only filled in if CountLines is set, and is 0
+	// otherwise.
+	StartLine int
+	// Whether this is the last chunk of the stream.
+	Final bool
+	// The boundary that terminated the chunk, as passed by ReadWithBoundary.
+	Boundary []byte
+}
+
+// ReadMeta is like Read, but passes the callback a ChunkInfo describing each
+// chunk, rather than adding a method for every combination of details a
+// callback might need. Counting lines adds serial work to the scanner, so
+// StartLine is only filled in if CountLines is set; everything else is always
+// provided. To tell which chunk is Final, each chunk is held back until the
+// next one has been scanned.
+func (r *ParallelReader) ReadMeta(stream io.Reader, work func(info ChunkInfo)) Result {
+	r.countLines = r.CountLines
+	r.markFinal = true
+	defer func() { r.countLines, r.markFinal = false, false }()
+
+	err := r.run(stream, nil, func(c *chunk) {
+		chunk := c.ReadableBytes()
+		info := ChunkInfo{
+			Bytes:    chunk,
+			Index:    c.index,
+			Offset:   c.offset,
+			Final:    c.final,
+			Boundary: r.trailingBoundary(chunk),
+		}
+		if r.CountLines {
+			info.StartLine = c.startLine
+		}
+		work(info)
+	})
+	return r.finish(err)
+}
+
+// finalMarker sits between the scanner and emit, holding back the most recent
+// chunk until either another is scanned or the stream ends, when it's marked
+// as the final chunk.
+type finalMarker struct {
+	pool *Pool
+	emit func(c *chunk) bool
+	held *chunk
+}
+
+// add holds c, emitting the chunk held before it. It returns false if emitting
+// was stopped.
+func (f *finalMarker) add(c *chunk) bool {
+	if f.held != nil && !f.emit(f.held) {
+		f.held = nil
+		f.pool.Return(c.buffer)
+		return false
+	}
+	f.held = c
+	return true
+}
+
+// flush emits the held chunk at the end of the stream, marking it as final if
+// the whole stream was read.
+func (f *finalMarker) flush(final bool) bool {
+	if f.held == nil {
+		return true
+	}
+	c := f.held
+	f.held = nil
+	c.final = final
+	return f.emit(c)
+}
@@ -0,0 +1,83 @@
+package rip
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadMeta(t *testing.T) {
+	assert := assert.New(t)
+
+	read := func(r *ParallelReader, input string) []ChunkInfo {
+		var mu sync.Mutex
+		var infos []ChunkInfo
+		result := r.ReadMeta(strings.NewReader(input), func(info ChunkInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			info.Bytes = append([]byte(nil), info.Bytes...)
+			info.Boundary = append([]byte(nil), info.Boundary...)
+			infos = append(infos, info)
+		})
+		assert.True(result.Completed)
+
+		sort.Slice(infos, func(i, j int) bool { return infos[i].Index < infos[j].Index })
+		return infos
+	}
+
+	t.Run("describes each chunk", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.ChunkBoundaries = []string{"\n", "\r\n"}
+
+		infos := read(r, "abc\r\ndef\nghi\r\njkl")
+
+		assert.Equal([]ChunkInfo{
+			{Bytes: []byte("abc\r\n"), Index: 0, Offset: 0, Boundary: []byte("\r\n")},
+			{Bytes: []byte("def\n"), Index: 1, Offset: 5, Boundary: []byte("\n")},
+			{Bytes: []byte("ghi\r\n"), Index: 2, Offset: 9, Boundary: []byte("\r\n")},
+			{Bytes: []byte("jkl"), Index: 3, Offset: 14, Final: true},
+		}, infos)
+	})
+
+	t.Run("with CountLines", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.CountLines = true
+
+		infos := read(r, "a\nb\nc\nd\ne\nf\n")
+
+		if assert.Len(infos, 2) {
+			assert.Equal(1, infos[0].StartLine)
+			assert.Equal(5, infos[1].StartLine)
+			assert.True(infos[1].Final)
+		}
+	})
+
+	t.Run("with CoalesceFinal", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.CoalesceFinal = true
+		r.MinChunkSize = 4
+
+		infos := read(r, "abcdefg\nhijklmn\no\n")
+
+		if assert.Len(infos, 2) {
+			assert.False(infos[0].Final)
+			assert.Equal("hijklmn\no\n", string(infos[1].Bytes))
+			assert.True(infos[1].Final)
+		}
+	})
+
+	t.Run("doesn't hold back chunks for other methods", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		r.ReadMeta(strings.NewReader("abc\n"), func(info ChunkInfo) {})
+		assert.False(r.markFinal)
+		assert.False(r.countLines)
+	})
+}
@@ -0,0 +1,86 @@
+package rip
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// Metrics are running totals of a ParallelReader's work over all of its reads
+// so far, for exporting to monitoring, along with how busy its workers are
+// right now. Unlike Stats, they aren't reset at the start of each read, so
+// they can be graphed as counters. Calls to ReadWith aren't included, other
+// than in the pool's counts, since they share its pool.
+type Metrics struct {
+	// The number of chunks, and the total number of bytes in them, that workers
+	// have finished with.
+	Chunks int64 `json:"chunks"`
+	Bytes  int64 `json:"bytes"`
+
+	// The number of buffers borrowed from the pool that were reused, and the
+	// number that had to be allocated because the pool was empty. Lots of misses
+	// compared to hits suggest PoolSize is too small, or that the pool isn't
+	// warm.
+	PoolHits   int64 `json:"pool_hits"`
+	PoolMisses int64 `json:"pool_misses"`
+
+	// The number of callbacks running right now, out of Concurrency workers;
+	// BusyWorkers over Concurrency gives the workers' utilization.
+	BusyWorkers int64 `json:"busy_workers"`
+	Concurrency int   `json:"concurrency"`
+}
+
+// Metrics returns the reader's Metrics. It's safe to call from another
+// goroutine while a read is running.
+func (r *ParallelReader) Metrics() Metrics {
+	return Metrics{
+		Chunks:      atomic.LoadInt64(&r.processed.totalChunks),
+		Bytes:       atomic.LoadInt64(&r.processed.totalBytes),
+		PoolHits:    atomic.LoadInt64(&r.poolCounts.hits),
+		PoolMisses:  atomic.LoadInt64(&r.poolCounts.misses),
+		BusyWorkers: atomic.LoadInt64(&r.busy),
+		Concurrency: r.Concurrency,
+	}
+}
+
+// Utilization returns the fraction of the Concurrency workers that are running
+// a callback right now, from 0 when they're all idle to 1 when they're all busy.
+// It's a better signal than how many chunks are queued for deciding whether
+// more workers would help, since a full queue with idle workers means the
+// callbacks aren't the bottleneck. Like Metrics, it's safe to call from another
+// goroutine while a read is running.
+func (r *ParallelReader) Utilization() float64 {
+	if r.Concurrency <= 0 {
+		return 0
+	}
+	return min(float64(atomic.LoadInt64(&r.busy))/float64(r.Concurrency), 1)
+}
+
+// MetricsVar returns a live view of the reader's Metrics as JSON, which
+// implements expvar.Var, so that they can be published without this package
+// depending on expvar:
+//
+//	expvar.Publish("rip", r.MetricsVar())
+//
+// For Prometheus, a collector can read Metrics directly.
+func (r *ParallelReader) MetricsVar() fmt.Stringer {
+	return metricsVar{r}
+}
+
+type metricsVar struct {
+	r *ParallelReader
+}
+
+func (v metricsVar) String() string {
+	data, _ := json.Marshal(v.r.Metrics())
+	return string(data)
+}
+
+// poolCounts counts a pool's hits and misses, for Metrics. It belongs to the
+// reader rather than the pool, so that the counts outlive a pool that's
+// replaced when the reader's configuration changes, and it's allocated once
+// and shared with the reader's copies, along with the pool.
+type poolCounts struct {
+	hits   int64
+	misses int64
+}
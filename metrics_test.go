@@ -0,0 +1,136 @@
+package rip
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("accumulates over reads", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		r.Read(strings.NewReader("abc\ndef\n"), func(chunk []byte) {})
+		r.Read(strings.NewReader("ghi\n"), func(chunk []byte) {})
+
+		m := r.Metrics()
+		assert.EqualValues(3, m.Chunks)
+		assert.EqualValues(12, m.Bytes)
+		assert.Zero(m.BusyWorkers)
+		assert.Equal(r.Concurrency, m.Concurrency)
+	})
+
+	t.Run("counts pool hits and misses", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		r.Read(strings.NewReader(strings.Repeat("abc\n", 100)), func(chunk []byte) {})
+		cold := r.Metrics()
+		assert.Positive(cold.PoolMisses)
+		assert.EqualValues(100, cold.PoolHits+cold.PoolMisses)
+
+		r.Warm()
+		r.Read(strings.NewReader(strings.Repeat("abc\n", 100)), func(chunk []byte) {})
+		warm := r.Metrics()
+		assert.EqualValues(200, warm.PoolHits+warm.PoolMisses)
+		assert.Greater(warm.PoolHits, cold.PoolHits)
+	})
+
+	t.Run("counts the pool use of concurrent calls to ReadWith", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.ReadWith(strings.NewReader(strings.Repeat("abc\n", 100)), func(chunk []byte) {})
+			}()
+		}
+		wg.Wait()
+
+		m := r.Metrics()
+		assert.EqualValues(400, m.PoolHits+m.PoolMisses)
+		assert.Zero(m.Chunks)
+	})
+
+	t.Run("reports busy workers during a read", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 2
+		r.ChunkSize = 4
+
+		release := make(chan struct{})
+		var started sync.WaitGroup
+		started.Add(2)
+		go func() {
+			started.Wait()
+			assert.EqualValues(2, r.Metrics().BusyWorkers)
+			close(release)
+		}()
+
+		r.Read(strings.NewReader("abc\ndef\n"), func(chunk []byte) {
+			started.Done()
+			<-release
+		})
+		assert.Zero(r.Metrics().BusyWorkers)
+	})
+
+	t.Run("publishes with expvar", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		// expvar names are global, so each run of the test needs its own.
+		name := fmt.Sprintf("rip_test_metrics_%d", time.Now().UnixNano())
+		expvar.Publish(name, r.MetricsVar())
+		r.Read(strings.NewReader("abc\ndef\n"), func(chunk []byte) {})
+
+		var m Metrics
+		if assert.NoError(json.Unmarshal([]byte(expvar.Get(name).String()), &m)) {
+			assert.Equal(r.Metrics(), m)
+		}
+	})
+}
+
+func TestUtilization(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("is the fraction of workers running a callback", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 4
+		r.ChunkSize = 4
+
+		release := make(chan struct{})
+		var started sync.WaitGroup
+		started.Add(2)
+
+		var during float64
+		go func() {
+			started.Wait()
+			during = r.Utilization()
+			close(release)
+		}()
+
+		// Only two chunks, so only two of the four workers ever get one.
+		r.Read(strings.NewReader("abc\ndef\n"), func(chunk []byte) {
+			started.Done()
+			<-release
+		})
+
+		assert.Equal(0.5, during)
+		assert.Zero(r.Utilization())
+	})
+
+	t.Run("before a read", func(t *testing.T) {
+		assert.Zero(NewParallelReader().Utilization())
+	})
+}
@@ -0,0 +1,43 @@
+package rip
+
+// withMiddleware returns fn wrapped in r.Middleware, if it's set, for a single
+// worker to call with batches of up to batchSize chunks. It's not safe for
+// concurrent use, so each worker needs its own. Chunks the middleware doesn't
+// pass on to next are handed to r.skipped instead, if it's set.
+func (r *ParallelReader) withMiddleware(batchSize int, fn func(batch []*chunk)) func(batch []*chunk) {
+	if r.Middleware == nil {
+		return fn
+	}
+
+	skipped := r.skipped
+	var current []*chunk
+	var called bool
+	next := r.Middleware(func([]byte) {
+		called = true
+		fn(current)
+	})
+	return func(batch []*chunk) {
+		current, called = batch, false
+		var chunk []byte
+		if batchSize == 1 {
+			chunk = batch[0].ReadableBytes()
+		}
+		next(chunk)
+		if !called && skipped != nil {
+			for _, c := range batch {
+				skipped(c)
+			}
+		}
+	}
+}
+
+// Chain combines middlewares into one, for Middleware, with the first being
+// the outermost: it's called first, and its next is the second.
+func Chain(middlewares ...func(next func(chunk []byte)) func(chunk []byte)) func(next func(chunk []byte)) func(chunk []byte) {
+	return func(next func(chunk []byte)) func(chunk []byte) {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
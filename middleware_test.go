@@ -0,0 +1,164 @@
+package rip
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	input := strings.Repeat("abc\n", 100)
+
+	t.Run("wraps each call of the callback", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 4
+		r.ChunkSize = 4
+
+		var composed, wrapped, processed int64
+		r.Middleware = func(next func(chunk []byte)) func(chunk []byte) {
+			atomic.AddInt64(&composed, 1)
+			return func(chunk []byte) {
+				assert.Equal("abc\n", string(chunk))
+				atomic.AddInt64(&wrapped, 1)
+				next(chunk)
+			}
+		}
+
+		r.Read(strings.NewReader(input), func(chunk []byte) {
+			atomic.AddInt64(&processed, 1)
+		})
+
+		assert.EqualValues(4, composed)
+		assert.EqualValues(100, wrapped)
+		assert.EqualValues(100, processed)
+	})
+
+	t.Run("can skip chunks", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.Middleware = func(next func(chunk []byte)) func(chunk []byte) {
+			return func(chunk []byte) {
+				if chunk[0] != '#' {
+					next(chunk)
+				}
+			}
+		}
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("abc\n#no\ndef\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+	})
+
+	skipComments := func(next func(chunk []byte)) func(chunk []byte) {
+		return func(chunk []byte) {
+			if chunk[0] != '#' {
+				next(chunk)
+			}
+		}
+	}
+
+	t.Run("can skip chunks with Transform", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 2
+		r.ChunkSize = 4
+		r.Middleware = skipComments
+
+		var out strings.Builder
+		err := r.Transform(strings.NewReader("abc\n#no\ndef\n#no\nghi\n"), &out, func(chunk []byte) []byte {
+			return chunk
+		})
+
+		assert.NoError(err)
+		assert.Equal("abc\ndef\nghi\n", out.String())
+	})
+
+	t.Run("can skip chunks with PipeOrdered", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 2
+		r.ChunkSize = 4
+		r.Middleware = skipComments
+
+		out := make(chan string, 128)
+		result := PipeOrdered(r, strings.NewReader("abc\n#no\ndef\n#no\nghi\n"), func(chunk []byte) string {
+			return string(chunk)
+		}, out)
+		close(out)
+
+		assert.NoError(result.Err)
+		assert.Equal([]string{"abc\n", "def\n", "ghi\n"}, drain(out))
+	})
+
+	t.Run("with ParallelScan", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+		r.ParallelScan = true
+
+		var wrapped int64
+		r.Middleware = func(next func(chunk []byte)) func(chunk []byte) {
+			return func(chunk []byte) {
+				atomic.AddInt64(&wrapped, 1)
+				next(chunk)
+			}
+		}
+
+		result := r.Read(strings.NewReader(input), func(chunk []byte) {})
+		assert.Equal(result.Stats.Chunks, wrapped)
+	})
+
+	t.Run("with ReadBatches", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.BatchSize = 8
+
+		var wrapped int64
+		r.Middleware = func(next func(chunk []byte)) func(chunk []byte) {
+			return func(chunk []byte) {
+				assert.Nil(chunk)
+				atomic.AddInt64(&wrapped, 1)
+				next(chunk)
+			}
+		}
+
+		var batches int64
+		r.ReadBatches(strings.NewReader(input), func(batch [][]byte) {
+			atomic.AddInt64(&batches, 1)
+		})
+		assert.Equal(batches, wrapped)
+	})
+}
+
+func TestChain(t *testing.T) {
+	assert := assert.New(t)
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(name string) func(next func(chunk []byte)) func(chunk []byte) {
+		return func(next func(chunk []byte)) func(chunk []byte) {
+			return func(chunk []byte) {
+				mu.Lock()
+				calls = append(calls, name)
+				mu.Unlock()
+				next(chunk)
+			}
+		}
+	}
+
+	r := NewParallelReader()
+	r.Middleware = Chain(record("outer"), record("inner"))
+	r.Read(strings.NewReader("abc\n"), func(chunk []byte) {
+		mu.Lock()
+		calls = append(calls, "work")
+		mu.Unlock()
+	})
+
+	assert.Equal([]string{"outer", "inner", "work"}, calls)
+}
@@ -0,0 +1,17 @@
+package rip
+
+import "io"
+
+// ReadMulti is like Read, but passes each chunk to every one of works, for
+// running several independent analyses over a stream in a single pass. Each
+// chunk is handled by one worker, which calls works in order, so the
+// callbacks for different chunks run in parallel but a chunk's buffer is only
+// reused once all of them have returned. Callbacks must not modify chunk, as
+// it's shared between them.
+func (r *ParallelReader) ReadMulti(stream io.Reader, works ...func(chunk []byte)) Result {
+	return r.Read(stream, func(chunk []byte) {
+		for _, work := range works {
+			work(chunk)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package rip
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadMulti(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewParallelReader()
+	r.ChunkSize = 8
+
+	var lines, bytesRead int64
+	r.ReadMulti(strings.NewReader(strings.Repeat("abc\n", 100)),
+		func(chunk []byte) {
+			atomic.AddInt64(&lines, int64(bytes.Count(chunk, []byte("\n"))))
+		},
+		func(chunk []byte) {
+			atomic.AddInt64(&bytesRead, int64(len(chunk)))
+		},
+	)
+
+	assert.EqualValues(100, lines)
+	assert.EqualValues(400, bytesRead)
+}
@@ -0,0 +1,58 @@
+package rip
+
+import "io"
+
+// An Option overrides a setting of a ParallelReader for a single call to
+// ReadWith.
+type Option func(r *ParallelReader)
+
+// WithConcurrency overrides Concurrency.
+func WithConcurrency(n int) Option {
+	return func(r *ParallelReader) { r.Concurrency = n }
+}
+
+// WithChunkSize overrides ChunkSize.
+func WithChunkSize(size int) Option {
+	return func(r *ParallelReader) { r.ChunkSize = size }
+}
+
+// WithChunkBoundary overrides ChunkBoundary.
+func WithChunkBoundary(boundary string) Option {
+	return func(r *ParallelReader) { r.ChunkBoundary = boundary }
+}
+
+// WithRequireBoundary overrides RequireBoundary.
+func WithRequireBoundary(require bool) Option {
+	return func(r *ParallelReader) { r.RequireBoundary = require }
+}
+
+// WithBufferAlignment overrides BufferAlignment.
+func WithBufferAlignment(n int) Option {
+	return func(r *ParallelReader) { r.BufferAlignment = n }
+}
+
+// ReadWith is like Read, but applies opts to a copy of r for the duration of
+// the call, leaving r itself unchanged. This lets a reader configured once be
+// shared as a template, with the occasional call tweaking it, and concurrent
+// calls to ReadWith are safe as long as r isn't modified. Stats on r don't
+// reflect calls to ReadWith; use the returned Result instead.
+func (r *ParallelReader) ReadWith(stream io.Reader, work func(chunk []byte), opts ...Option) Result {
+	return r.with(opts).Read(stream, work)
+}
+
+// with returns a copy of r with opts applied. The copy shares r's pool, and
+// its counts, which are safe to use concurrently, but none of its other per-run
+// state.
+func (r *ParallelReader) with(opts []Option) *ParallelReader {
+	c := &ParallelReader{}
+	*c = *r
+	c.poolCounts = r.poolCounts
+	c.chunks = nil
+	c.stats = new(Stats)
+	c.completed = false
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
@@ -0,0 +1,50 @@
+package rip
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadWith(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("applies options for the call only", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		chunks := make(chan string, 128)
+		result := r.ReadWith(strings.NewReader("abc;def;"), func(chunk []byte) {
+			chunks <- string(chunk)
+		}, WithChunkBoundary(";"), WithChunkSize(16))
+		close(chunks)
+
+		assert.Equal([]string{"abc;def;"}, drain(chunks))
+		assert.True(result.Completed)
+		assert.Equal("\n", r.ChunkBoundary)
+		assert.Equal(4, r.ChunkSize)
+		assert.Zero(r.Stats().Chunks)
+	})
+
+	t.Run("with concurrent calls on a shared reader", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var wg sync.WaitGroup
+		results := make([]Result, 8)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = r.ReadWith(strings.NewReader("abc\ndef\n"), func(chunk []byte) {}, WithConcurrency(2))
+			}()
+		}
+		wg.Wait()
+
+		for _, result := range results {
+			assert.EqualValues(2, result.Stats.Chunks)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package rip
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+// PanicError is the error reported for a panic in a callback that was
+// recovered because RecoverPanics is set.
+type PanicError struct {
+	// The value passed to panic.
+	Value any
+	// The stack of the goroutine that panicked, as reported by debug.Stack.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("rip: callback panicked: %v", e.Value)
+}
+
+// Unwrap returns Value if it's an error, so errors.Is and errors.As can see
+// through a panic(err).
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// finish returns the Result of a read that ended with err. Unless
+// RecoverPanics is set, a non-nil err is a panic, as it always has been; with
+// it, err is reported in Result.Err instead.
+func (r *ParallelReader) finish(err error) Result {
+	if err != nil && !r.RecoverPanics {
+		panic(err)
+	}
+
+	res := r.result()
+	res.Err = err
+	return res
+}
+
+// panicState collects the first panic recovered during a read, and tells the
+// producer to stop once there's been one.
+type panicState struct {
+	once sync.Once
+	err  error
+	stop chan struct{}
+}
+
+// watchPanics prepares to recover panics from callbacks for a read, if
+// RecoverPanics is set, returning a channel that's closed when either done is
+// or a callback panics, to be used in place of done. The returned function
+// must be called once the read is over; it returns the recovered panic, if
+// there was one.
+func (r *ParallelReader) watchPanics(done <-chan struct{}) (<-chan struct{}, func() error) {
+	r.panics = nil
+	if !r.RecoverPanics {
+		return done, func() error { return nil }
+	}
+
+	p := &panicState{stop: make(chan struct{})}
+	r.panics = p
+
+	if done != nil {
+		go func() {
+			select {
+			case <-done:
+				p.stopOnce(nil)
+			case <-p.stop:
+			}
+		}()
+	}
+
+	return p.stop, func() error {
+		p.stopOnce(nil)
+		return p.err
+	}
+}
+
+// stopOnce closes stop the first time it's called, recording err.
+func (p *panicState) stopOnce(err error) {
+	p.once.Do(func() {
+		p.err = err
+		close(p.stop)
+	})
+}
+
+// stopped reports whether a callback has panicked or the read has otherwise
+// been stopped.
+func (p *panicState) stopped() bool {
+	select {
+	case <-p.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// call calls fn, recovering and recording any panic in p, the run's
+// panicState, if RecoverPanics is set. Once the read has been stopped by a
+// panic, fn isn't called at all. It reports whether fn ran to completion. While
+// fn runs, it counts towards Metrics.BusyWorkers.
+func (r *ParallelReader) call(p *panicState, fn func()) (ok bool) {
+	atomic.AddInt64(&r.busy, 1)
+	defer atomic.AddInt64(&r.busy, -1)
+
+	if p == nil {
+		fn()
+		return true
+	}
+	if p.stopped() {
+		return false
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			p.stopOnce(&PanicError{Value: v, Stack: debug.Stack()})
+		}
+	}()
+	fn()
+	return true
+}
@@ -0,0 +1,109 @@
+package rip
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverPanics(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("reports a panic in a callback", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.RecoverPanics = true
+
+		var calls int64
+		// A stream that can't be seeked is scanned once, and sent to workers.
+		stream := io.MultiReader(strings.NewReader(strings.Repeat("abc\n", 1000)))
+		result := r.Read(stream, func(chunk []byte) {
+			if atomic.AddInt64(&calls, 1) == 1 {
+				panic("boom")
+			}
+		})
+
+		var panicErr *PanicError
+		if assert.ErrorAs(result.Err, &panicErr) {
+			assert.Equal("boom", panicErr.Value)
+			assert.NotEmpty(panicErr.Stack)
+			assert.Equal("rip: callback panicked: boom", panicErr.Error())
+		}
+		assert.False(result.Completed)
+		assert.Less(atomic.LoadInt64(&calls), int64(1000))
+	})
+
+	t.Run("unwraps a panicked error", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RecoverPanics = true
+
+		result := r.Read(strings.NewReader("abc\n"), func(chunk []byte) {
+			panic(io.ErrShortWrite)
+		})
+
+		assert.ErrorIs(result.Err, io.ErrShortWrite)
+	})
+
+	t.Run("reports a stream error", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RecoverPanics = true
+
+		result := r.Read(io.MultiReader(strings.NewReader("abc\n"), iotest.ErrReader(errTest)), func(chunk []byte) {})
+
+		assert.ErrorIs(result.Err, errTest)
+	})
+
+	t.Run("from ReadContextWork", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RecoverPanics = true
+
+		err := r.ReadContextWork(context.Background(), strings.NewReader("abc\n"), func(ctx context.Context, chunk []byte) error {
+			panic("boom")
+		})
+
+		var panicErr *PanicError
+		assert.ErrorAs(err, &panicErr)
+	})
+
+	t.Run("when reading in parallel sections", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.RecoverPanics = true
+
+		result := r.Read(strings.NewReader(strings.Repeat("abc\n", 100)), func(chunk []byte) {
+			panic("boom")
+		})
+
+		var panicErr *PanicError
+		assert.ErrorAs(result.Err, &panicErr)
+	})
+
+	t.Run("the next read starts afresh", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RecoverPanics = true
+
+		r.Read(strings.NewReader("abc\n"), func(chunk []byte) {
+			panic("boom")
+		})
+		result := r.Read(strings.NewReader("abc\n"), func(chunk []byte) {})
+
+		assert.NoError(result.Err)
+		assert.True(result.Completed)
+	})
+
+	t.Run("without RecoverPanics", func(t *testing.T) {
+		r := NewParallelReader()
+
+		assert.PanicsWithError(errTest.Error(), func() {
+			r.Read(io.MultiReader(strings.NewReader("abc\n"), iotest.ErrReader(errTest)), func(chunk []byte) {})
+		})
+	})
+}
+
+var errTest = errors.New("test error")
@@ -0,0 +1,150 @@
+package rip
+
+import (
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// A stream whose size can be found and that can be read from at any offset.
+type readSeekerAt interface {
+	io.ReaderAt
+	io.Seeker
+}
+
+// seekable returns stream as a readSeekerAt if it can be read from at any
+// offset. Files are only considered seekable if they're regular files, so that
+// pipes and terminals (like stdin often is) are never seeked.
+func seekable(stream io.Reader) (readSeekerAt, bool) {
+	if file, ok := stream.(*os.File); ok {
+		info, err := file.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil, false
+		}
+	}
+
+	source, ok := stream.(readSeekerAt)
+	return source, ok
+}
+
+// readSeekerParallel calls readParallel on the remainder of source, from its
+// current position to the end, and then leaves it positioned at the end.
+func (r *ParallelReader) readSeekerParallel(source readSeekerAt, fn func(c *chunk)) error {
+	start, err := source.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	end, err := source.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	return r.readParallel(source, start, end, fn)
+}
+
+// readParallel splits source between start and end into Concurrency sections
+// that each begin at the start of a record, then scans and calls fn for the
+// chunks of every section in parallel. There's no need to copy chunks into
+// pooled buffers, since each section's scanner is only read from by the
+// goroutine that calls fn.
+func (r *ParallelReader) readParallel(source io.ReaderAt, start, end int64, fn func(c *chunk)) error {
+	fn = r.normalizingLineEndings(fn)
+
+	_, recovered := r.watchPanics(nil)
+
+	// AutoChunkSize isn't supported here, so make sure a target left over from
+	// a previous read isn't used.
+	r.autoChunkSize = 0
+	r.stats = new(Stats)
+	r.processed.reset()
+	r.completed = false
+
+	if r.RejectBinary {
+		sample := make([]byte, binarySampleSize)
+		n, err := source.ReadAt(sample, start)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if r.looksBinary(sample[:min(int64(n), end-start)]) {
+			return ErrBinaryInput
+		}
+	}
+
+	starts := make([]int64, r.Concurrency+1)
+	starts[0] = start
+	starts[r.Concurrency] = end
+
+	for i := 1; i < r.Concurrency; i++ {
+		sectionStart, err := r.recordStart(source, start+(end-start)*int64(i)/int64(r.Concurrency))
+		if err != nil {
+			return err
+		}
+		if sectionStart < 0 || sectionStart > end {
+			sectionStart = end
+		}
+		starts[i] = sectionStart
+	}
+
+	errs := make([]error, r.Concurrency)
+
+	var wg sync.WaitGroup
+	wg.Add(r.Concurrency)
+	for i := 0; i < r.Concurrency; i++ {
+		go func(i int) {
+			defer wg.Done()
+			if r.LockWorkerThreads {
+				runtime.LockOSThread()
+				defer runtime.UnlockOSThread()
+			}
+			section := io.NewSectionReader(source, starts[i], starts[i+1]-starts[i])
+			errs[i] = r.scanSection(section, starts[i], i == r.Concurrency-1, fn)
+		}(i)
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if panicErr := recovered(); panicErr != nil {
+		err = panicErr
+	}
+	r.completed = err == nil
+	return r.checkRecordCount(err)
+}
+
+// scanSection scans section, which begins at offset in the whole stream, and
+// calls fn with each chunk directly from the scanner's buffer.
+func (r *ParallelReader) scanSection(section io.Reader, offset int64, last bool, fn func(c *chunk)) error {
+	scanner, pos := r.newScanner(section)
+	sample := r.newSampler(offset)
+	work := r.withMiddleware(1, func(batch []*chunk) { fn(batch[0]) })
+
+	for scanner.Scan() {
+		token := scanner.Bytes()
+
+		// Every section but the last ends exactly on a boundary, so only the last
+		// section can have a final empty chunk.
+		if len(token) == 0 && !(last && r.EmitEmptyFinalChunk) {
+			continue
+		}
+		if r.ExpectedRecords > 0 {
+			atomic.AddInt64(&r.stats.Records, r.recordsIn(token))
+		}
+		if (r.Filter != nil && !r.Filter(token)) || (sample != nil && !sample.keep()) {
+			continue
+		}
+
+		c := &chunk{buffer: token, readableSize: len(token), index: -1, offset: offset + pos.tokenOffset}
+		r.countChunk(c)
+		r.call(r.panics, func() { work([]*chunk{c}) })
+		r.processed.add(c)
+
+		// Once a callback has panicked, there's no point scanning any further.
+		if r.panics != nil && r.panics.stopped() {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
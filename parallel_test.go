@@ -0,0 +1,78 @@
+package rip
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelScan(t *testing.T) {
+	assert := assert.New(t)
+
+	var input strings.Builder
+	var records []string
+	for i := 0; i < 1000; i++ {
+		record := fmt.Sprintf("record %d %s\n", i, strings.Repeat("x", i%13))
+		records = append(records, record)
+		input.WriteString(record)
+	}
+
+	for _, concurrency := range []int{1, 3, 8} {
+		t.Run(fmt.Sprintf("with Concurrency %d", concurrency), func(t *testing.T) {
+			r := NewParallelReader()
+			r.Concurrency = concurrency
+			r.ChunkSize = 64
+			r.ParallelScan = true
+
+			chunks := make(chan string, 2048)
+			r.Read(strings.NewReader(input.String()), func(chunk []byte) {
+				for _, record := range strings.SplitAfter(string(chunk), "\n") {
+					if record != "" {
+						chunks <- record
+					}
+				}
+			})
+			close(chunks)
+
+			assert.ElementsMatch(records, drain(chunks))
+		})
+	}
+
+	t.Run("when the stream has no trailing boundary", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 4
+		r.ChunkSize = 8
+		r.ParallelScan = true
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("abc\ndef\nghi\njkl"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.Equal("abc\ndef\nghi\njkl", strings.Join(sortedStrings(drain(chunks)), ""))
+	})
+
+	t.Run("when a record is shorter than Concurrency bytes", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 3
+		r.ChunkSize = 8
+		r.ParallelScan = true
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("ab\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.Equal([]string{"ab\n"}, drain(chunks))
+	})
+}
+
+func sortedStrings(s []string) []string {
+	sort.Strings(s)
+	return s
+}
@@ -0,0 +1,40 @@
+package rip
+
+import (
+	"bufio"
+	"io"
+)
+
+// Peek returns the first record in stream, i.e. everything up to and including
+// the first ChunkBoundary, without losing any data: rest replays the first
+// record followed by the remainder of stream, so it can be passed straight to
+// Read once the first record has been inspected (for example, to infer a
+// schema from a header line).
+//
+// If stream ends before a ChunkBoundary is found, first holds all of it. If no
+// ChunkBoundary is found within ChunkSize bytes, bufio.ErrTooLong is returned.
+// first is a copy and may be retained.
+func (r *ParallelReader) Peek(stream io.Reader) (first []byte, rest io.Reader, err error) {
+	br := bufio.NewReaderSize(stream, r.ChunkSize)
+	boundary := []byte(r.ChunkBoundary)
+
+	for n := 512; ; n *= 2 {
+		if n > br.Size() {
+			n = br.Size()
+		}
+
+		data, err := br.Peek(n)
+		if idx := r.index(data, boundary); idx > -1 {
+			return append([]byte(nil), data[:idx+len(boundary)]...), br, nil
+		}
+
+		switch {
+		case err == io.EOF:
+			return append([]byte(nil), data...), br, nil
+		case err != nil && err != bufio.ErrBufferFull:
+			return nil, br, err
+		case n == br.Size():
+			return nil, br, bufio.ErrTooLong
+		}
+	}
+}
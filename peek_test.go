@@ -0,0 +1,50 @@
+package rip
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeek(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("returns the first record without consuming it", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+
+		first, rest, err := r.Peek(strings.NewReader("id,name\n1,abc\n2,def\n"))
+		assert.NoError(err)
+		assert.Equal("id,name\n", string(first))
+
+		var out bytes.Buffer
+		r.Transform(rest, &out, func(chunk []byte) []byte {
+			return chunk
+		})
+
+		assert.Equal("id,name\n1,abc\n2,def\n", out.String())
+	})
+
+	t.Run("when the stream has no boundary", func(t *testing.T) {
+		r := NewParallelReader()
+
+		first, rest, err := r.Peek(strings.NewReader("abc"))
+		assert.NoError(err)
+		assert.Equal("abc", string(first))
+
+		data, _ := io.ReadAll(rest)
+		assert.Equal("abc", string(data))
+	})
+
+	t.Run("when the first record is longer than ChunkSize", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+
+		_, _, err := r.Peek(strings.NewReader(strings.Repeat("x", 32) + "\n"))
+		assert.ErrorIs(err, bufio.ErrTooLong)
+	})
+}
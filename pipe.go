@@ -0,0 +1,82 @@
+package rip
+
+import "io"
+
+// Pipe reads stream using r, calling fn for each chunk from a pool of
+// goroutines, and sends each value it returns to out, for plugging a read into
+// a larger channel-based pipeline whose next stage owns out. Workers wait for
+// room in out, so its capacity is the backpressure on the read. Values are sent
+// in whatever order their chunks finish; use PipeOrdered to keep the order of
+// the stream.
+//
+// Pipe returns once the stream is exhausted and every value has been sent. It
+// never closes out, which is left to its owner. The Result and panics are as
+// for Read. It's a function rather than a method because Go methods can't have
+// type parameters.
+func Pipe[T any](r *ParallelReader, stream io.Reader, fn func(chunk []byte) T, out chan<- T) Result {
+	err := r.run(stream, nil, func(c *chunk) {
+		out <- fn(c.ReadableBytes())
+	})
+	return r.finish(err)
+}
+
+// PipeOrdered is like Pipe, but sends values to out in the order their chunks
+// appear in stream. As with Transform, values that finish early are held in a
+// reorder window of MaxReorderBuffer results until the ones before them have
+// been sent, so a slow chunk, or a slow consumer of out, holds up the workers
+// rather than memory growing without bound.
+func PipeOrdered[T any](r *ParallelReader, stream io.Reader, fn func(chunk []byte) T, out chan<- T) Result {
+	type result struct {
+		index int
+		value T
+		// Whether Middleware skipped the chunk, so there's no value to send.
+		skipped bool
+	}
+
+	results := make(chan result, r.Concurrency)
+	window := r.newReorderWindow()
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+
+		pending := make(map[int]result)
+		next := 0
+		for res := range results {
+			pending[res.index] = res
+
+			for {
+				res, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+
+				if !res.skipped {
+					out <- res.value
+				}
+				window.advance(next)
+			}
+		}
+	}()
+
+	defer r.inOrder(func(c *chunk) {
+		window.wait(c.index)
+		results <- result{index: c.index, skipped: true}
+	})()
+
+	err := r.run(stream, nil, func(c *chunk) {
+		sent := false
+		defer window.abandon(&sent)
+
+		value := fn(c.ReadableBytes())
+		window.wait(c.index)
+		results <- result{index: c.index, value: value}
+		sent = true
+	})
+	close(results)
+	<-finished
+
+	return r.finish(err)
+}
@@ -0,0 +1,74 @@
+package rip
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipe(t *testing.T) {
+	assert := assert.New(t)
+
+	var input strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&input, "%04d\n", i)
+	}
+
+	parse := func(chunk []byte) int {
+		n, _ := strconv.Atoi(string(bytes.TrimSpace(chunk)))
+		return n
+	}
+
+	// pipe runs the pipe with a small out channel, read by a next stage that
+	// collects what's sent to it.
+	pipe := func(run func(out chan<- int) Result) ([]int, Result) {
+		out := make(chan int, 2)
+		collected := make(chan []int)
+		go func() {
+			var values []int
+			for v := range out {
+				values = append(values, v)
+			}
+			collected <- values
+		}()
+
+		result := run(out)
+		close(out)
+		return <-collected, result
+	}
+
+	t.Run("sends every value to out", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 5
+
+		values, result := pipe(func(out chan<- int) Result {
+			return Pipe(r, strings.NewReader(input.String()), parse, out)
+		})
+
+		assert.True(result.Completed)
+		assert.Len(values, 1000)
+		sort.Ints(values)
+		for i, v := range values {
+			assert.Equal(i, v)
+		}
+	})
+
+	t.Run("PipeOrdered sends values in input order", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 5
+		r.MaxReorderBuffer = 4
+
+		values, result := pipe(func(out chan<- int) Result {
+			return PipeOrdered(r, strings.NewReader(input.String()), parse, out)
+		})
+
+		assert.True(result.Completed)
+		assert.Len(values, 1000)
+		assert.True(sort.IntsAreSorted(values))
+	})
+}
@@ -0,0 +1,46 @@
+package rip
+
+import "io"
+
+// readFixedPrefetched is readFixed, but reads stream on a goroutine of its own
+// that can get up to Prefetch chunks ahead of the foreground, which sends them
+// on to the workers. Sending (and so counting stats) stays in the foreground,
+// as it does for every other producer.
+func (r *ParallelReader) readFixedPrefetched(stream io.Reader, done <-chan struct{}) error {
+	prefetched := make(chan *chunk, r.Prefetch)
+	stop := make(chan struct{})
+
+	var readErr error
+	go func() {
+		defer close(prefetched)
+		readErr = r.readFixedChunks(stream, func(c *chunk) bool {
+			select {
+			case prefetched <- c:
+				return true
+			case <-stop:
+				r.pool.Return(c.buffer)
+				return false
+			}
+		})
+	}()
+
+	for c := range prefetched {
+		if !r.send(c, done) {
+			// Stop the reader, and return the buffers of anything it had already
+			// read ahead.
+			close(stop)
+			for c := range prefetched {
+				r.pool.Return(c.buffer)
+			}
+			return nil
+		}
+	}
+
+	// The reader has finished, since prefetched is closed, so readErr is safe to
+	// read. It's only io.EOF if every chunk was sent.
+	if readErr == io.EOF {
+		r.completed = true
+		return nil
+	}
+	return readErr
+}
@@ -0,0 +1,62 @@
+package rip
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefetch(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("reads every chunk", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.Prefetch = 8
+
+		input := strings.Repeat("abcdefgh", 100) + "xy"
+
+		var mu sync.Mutex
+		chunks := map[int64]string{}
+		err := r.runFixed(strings.NewReader(input), nil, func(c *chunk) {
+			mu.Lock()
+			defer mu.Unlock()
+			chunks[c.offset] = string(c.ReadableBytes())
+		})
+
+		assert.NoError(err)
+		assert.True(r.result().Completed)
+		assert.EqualValues(201, r.Stats().Chunks)
+		assert.Len(chunks, 201)
+		assert.Equal("xy", chunks[800])
+	})
+
+	t.Run("when the read is stopped early", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.Prefetch = 8
+
+		done := make(chan struct{})
+		close(done)
+		err := r.runFixed(strings.NewReader(strings.Repeat("abcd", 100)), done, func(c *chunk) {})
+
+		assert.NoError(err)
+		assert.False(r.result().Completed)
+	})
+
+	t.Run("with a stream error", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.Prefetch = 8
+
+		stream := io.MultiReader(strings.NewReader("abcdefgh"), iotest.ErrReader(errTest))
+		err := r.runFixed(stream, nil, func(c *chunk) {})
+
+		assert.ErrorIs(err, errTest)
+		assert.False(r.result().Completed)
+	})
+}
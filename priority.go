@@ -0,0 +1,81 @@
+package rip
+
+import "container/heap"
+
+// prioritize starts a goroutine that receives chunks from in as they're sent,
+// and sends them on to the returned channel in order of Priority, highest
+// first, breaking ties in the order they were sent. Only the chunks waiting
+// between the two are ordered, so this only has an effect when the scanner has
+// got ahead of the workers. The returned channel is closed once in is, and
+// every chunk from it has been sent on.
+//
+// The heap holds at most as many chunks as in can, so with both full, the
+// scanner can get about twice as far ahead as without Priority set.
+func (r *ParallelReader) prioritize(in <-chan *chunk) chan *chunk {
+	out := make(chan *chunk)
+
+	go func() {
+		defer close(out)
+
+		var queue priorityQueue
+		var seq int64
+		for in != nil || queue.Len() > 0 {
+			// Only offer the most urgent chunk to the workers when there is one, and
+			// only receive more while in is still open and the queue isn't full.
+			var send chan *chunk
+			var next *chunk
+			if queue.Len() > 0 {
+				send = out
+				next = queue[0].chunk
+			}
+			receive := in
+			if queue.Len() >= cap(in) {
+				receive = nil
+			}
+
+			select {
+			case c, ok := <-receive:
+				if !ok {
+					in = nil
+					continue
+				}
+				heap.Push(&queue, prioritized{chunk: c, priority: r.Priority(c.ReadableBytes()), seq: seq})
+				seq++
+			case send <- next:
+				heap.Pop(&queue)
+			}
+		}
+	}()
+
+	return out
+}
+
+type prioritized struct {
+	chunk    *chunk
+	priority int
+	seq      int64
+}
+
+// priorityQueue is a heap of chunks, implementing heap.Interface, with the
+// highest priority first.
+type priorityQueue []prioritized
+
+func (q priorityQueue) Len() int { return len(q) }
+
+func (q priorityQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q priorityQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *priorityQueue) Push(x any) { *q = append(*q, x.(prioritized)) }
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,129 @@
+package rip
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriority(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("dispatches waiting chunks highest priority first", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Priority = func(chunk []byte) int {
+			return int(chunk[0] - '0')
+		}
+
+		in := make(chan *chunk, 5)
+		for _, data := range []string{"1a", "3a", "2a", "3b", "1b"} {
+			in <- &chunk{buffer: []byte(data), readableSize: len(data)}
+		}
+		close(in)
+
+		out := r.prioritize(in)
+
+		// Wait until every chunk is in the queue, so they can all be reordered.
+		assert.Eventually(func() bool { return len(in) == 0 }, time.Second, time.Millisecond)
+
+		var order []string
+		for c := range out {
+			order = append(order, string(c.ReadableBytes()))
+		}
+		assert.Equal([]string{"3a", "3b", "2a", "1a", "1b"}, order)
+	})
+
+	t.Run("reads every chunk", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 2
+		r.ChunkSize = 4
+		r.Priority = func(chunk []byte) int {
+			return len(chunk)
+		}
+
+		var mu sync.Mutex
+		var chunks []string
+		result := r.Read(strings.NewReader("ab\ncde\nf\nghi\n"), func(chunk []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			chunks = append(chunks, string(chunk))
+		})
+
+		sort.Strings(chunks)
+		assert.True(result.Completed)
+		assert.Equal([]string{"ab\n", "cde\n", "f\n", "ghi\n"}, chunks)
+	})
+
+	// Every hundredth chunk has a lower priority, so that with the heap it'd be
+	// held back behind later ones.
+	var input strings.Builder
+	for i := 0; i < 300; i++ {
+		if i%100 == 50 {
+			input.WriteString("z\n")
+		} else {
+			input.WriteString("a\n")
+		}
+	}
+	lowEvery100 := func(r *ParallelReader) {
+		r.Concurrency = 2
+		r.ChunkSize = 2
+		r.Priority = func(chunk []byte) int {
+			if chunk[0] == 'z' {
+				return 0
+			}
+			return 1
+		}
+	}
+
+	// within fails the test if fn takes more than 5s to return.
+	within := func(t *testing.T, fn func()) {
+		finished := make(chan struct{})
+		go func() {
+			defer close(finished)
+			fn()
+		}()
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	t.Run("is ignored by Transform", func(t *testing.T) {
+		for _, size := range []int{0, 2} {
+			r := NewParallelReader()
+			lowEvery100(r)
+			r.MaxReorderBuffer = size
+
+			var out strings.Builder
+			within(t, func() {
+				err := r.Transform(strings.NewReader(input.String()), &out, func(chunk []byte) []byte {
+					time.Sleep(time.Millisecond)
+					return chunk
+				})
+				assert.NoError(err)
+			})
+			assert.Equal(input.String(), out.String())
+		}
+	})
+
+	t.Run("is ignored by PipeOrdered", func(t *testing.T) {
+		r := NewParallelReader()
+		lowEvery100(r)
+
+		out := make(chan string, 300)
+		within(t, func() {
+			result := PipeOrdered(r, strings.NewReader(input.String()), func(chunk []byte) string {
+				time.Sleep(time.Millisecond)
+				return string(chunk)
+			}, out)
+			assert.NoError(result.Err)
+		})
+		close(out)
+		assert.Equal(input.String(), strings.Join(drain(out), ""))
+	})
+}
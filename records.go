@@ -0,0 +1,44 @@
+package rip
+
+import (
+	"bytes"
+	"iter"
+)
+
+// Records returns an iterator over the records in chunk, each of which ends
+// with boundary (included in the record), except possibly the last. It's meant
+// for splitting a chunk into individual records inside a callback, and yields
+// slices of chunk rather than copies, so it doesn't allocate per record. If
+// boundary is empty, chunk is yielded whole.
+func Records(chunk []byte, boundary string) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		if len(boundary) == 0 {
+			if len(chunk) > 0 {
+				yield(chunk)
+			}
+			return
+		}
+
+		sep := []byte(boundary)
+		for data := chunk; len(data) > 0; {
+			end := len(data)
+			if idx := bytes.Index(data, sep); idx > -1 {
+				end = idx + len(sep)
+			}
+			if !yield(data[:end]) {
+				return
+			}
+			data = data[end:]
+		}
+	}
+}
+
+// SubRead calls work with each record in chunk, as split by Records. Since it's
+// intended to be called from a callback that's already running on one of the
+// worker goroutines, it iterates over the records serially rather than
+// starting more goroutines of its own.
+func SubRead(chunk []byte, subBoundary string, work func(record []byte)) {
+	for record := range Records(chunk, subBoundary) {
+		work(record)
+	}
+}
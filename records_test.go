@@ -0,0 +1,67 @@
+package rip
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecords(t *testing.T) {
+	assert := assert.New(t)
+
+	collect := func(chunk, boundary string) []string {
+		var records []string
+		for record := range Records([]byte(chunk), boundary) {
+			records = append(records, string(record))
+		}
+		return records
+	}
+
+	t.Run("with a trailing boundary", func(t *testing.T) {
+		assert.Equal([]string{"a,", "bc,", "d,"}, collect("a,bc,d,", ","))
+	})
+
+	t.Run("without a trailing boundary", func(t *testing.T) {
+		assert.Equal([]string{"a||", "b||", "c"}, collect("a||b||c", "||"))
+	})
+
+	t.Run("with an empty boundary", func(t *testing.T) {
+		assert.Equal([]string{"abc"}, collect("abc", ""))
+	})
+
+	t.Run("with an empty chunk", func(t *testing.T) {
+		assert.Empty(collect("", ","))
+	})
+
+	t.Run("when iteration stops early", func(t *testing.T) {
+		var records []string
+		for record := range Records([]byte("a,b,c"), ",") {
+			records = append(records, string(record))
+			break
+		}
+		assert.Equal([]string{"a,"}, records)
+	})
+
+	t.Run("doesn't allocate per record", func(t *testing.T) {
+		chunk := []byte(strings.Repeat("record\n", 100))
+		var total int
+		allocs := testing.AllocsPerRun(100, func() {
+			for record := range Records(chunk, "\n") {
+				total += len(record)
+			}
+		})
+		assert.Zero(allocs)
+	})
+}
+
+func TestSubRead(t *testing.T) {
+	assert := assert.New(t)
+
+	var records []string
+	SubRead([]byte("[1],[2],[3]"), ",", func(record []byte) {
+		records = append(records, string(record))
+	})
+
+	assert.Equal([]string{"[1],", "[2],", "[3]"}, records)
+}
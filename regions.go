@@ -0,0 +1,123 @@
+package rip
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// ReadRegions is like Read, but splits stream into regions that begin with
+// ChunkBoundaryStart and end with ChunkBoundary, and passes the callback the
+// text between those regions too, with inside reporting which kind each chunk
+// is. This is for jobs like templating, where the delimited sections are
+// processed but the text around them has to be kept.
+//
+// Each region is delivered as a chunk of its own, including both delimiters,
+// and text outside regions is delivered in chunks of up to ChunkSize. Regions
+// longer than ChunkSize are buffered up to MaxChunkSize. A region that's still
+// open at the end of the stream is delivered as outside text. If
+// RequireBoundary is set, outside text is discarded rather than delivered, so
+// only regions reach the callback, as with Read.
+//
+// ReadRegions panics if ChunkBoundaryStart or ChunkBoundary isn't set.
+func (r *ParallelReader) ReadRegions(stream io.Reader, work func(chunk []byte, inside bool)) Result {
+	if r.ChunkBoundaryStart == "" || r.ChunkBoundary == "" {
+		panic(errors.New("rip: ReadRegions requires a ChunkBoundaryStart and ChunkBoundary"))
+	}
+
+	err := r.dispatch(r.scanRegions, stream, nil, func(c *chunk) {
+		work(c.ReadableBytes(), c.inside)
+	})
+	return r.finish(err)
+}
+
+// scanRegions is like scan, but splits stream with splitRegions.
+func (r *ParallelReader) scanRegions(stream io.Reader, done <-chan struct{}) error {
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, r.ChunkSize), r.maxChunkSize())
+
+	var consumed, tokenOffset int64
+	var inside bool
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := r.splitRegions(data, atEOF, &inside)
+		if token != nil {
+			tokenOffset = consumed + int64(cap(data)-cap(token))
+		}
+		consumed += int64(advance)
+		return advance, token, err
+	})
+
+	for index := 0; scanner.Scan(); {
+		token := scanner.Bytes()
+		if r.RequireBoundary && !inside {
+			continue
+		}
+
+		var buf []byte
+		if len(token) > r.ChunkSize {
+			buf = r.pool.alloc(len(token))
+		} else {
+			buf = r.pool.Borrow()
+		}
+		size := copy(buf, token)
+
+		c := &chunk{buffer: buf, readableSize: size, index: index, offset: tokenOffset, inside: inside}
+		if !r.send(c, done) {
+			return nil
+		}
+		index++
+	}
+
+	err := scanner.Err()
+	r.completed = err == nil
+	return err
+}
+
+// splitRegions is a bufio.SplitFunc that returns either a whole region from
+// ChunkBoundaryStart to ChunkBoundary, or the text up to the next region,
+// setting inside to say which.
+func (r *ParallelReader) splitRegions(data []byte, atEOF bool, inside *bool) (advance int, token []byte, err error) {
+	start, end := []byte(r.ChunkBoundaryStart), []byte(r.ChunkBoundary)
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if r.hasPrefix(data, start) {
+		if idx := r.index(data[len(start):], end); idx > -1 {
+			*inside = true
+			n := len(start) + idx + len(end)
+			return n, data[:n], nil
+		}
+		if !atEOF {
+			return 0, nil, nil
+		}
+		*inside = false
+		return len(data), data, nil
+	}
+
+	*inside = false
+	if idx := r.index(data, start); idx > -1 {
+		n := min(idx, r.ChunkSize)
+		return n, data[:n], nil
+	}
+	if atEOF {
+		n := min(len(data), r.ChunkSize)
+		return n, data[:n], nil
+	}
+	if len(data) < r.ChunkSize {
+		return 0, nil, nil
+	}
+
+	// Hold back a suffix that could turn out to be the start of a region.
+	n := min(len(data), r.ChunkSize)
+	for k := min(len(start)-1, n); k > 0; k-- {
+		if r.hasSuffix(data, start[:k]) {
+			n = min(n, len(data)-k)
+			break
+		}
+	}
+	if n == 0 {
+		return 0, nil, nil
+	}
+	return n, data[:n], nil
+}
@@ -0,0 +1,80 @@
+package rip
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRegions(t *testing.T) {
+	assert := assert.New(t)
+
+	input := "Hello {{name}}, you owe {{amount}}{{currency}}. {{unclosed"
+
+	t.Run("delivers regions and the text between them", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.ChunkBoundaryStart = "{{"
+		r.ChunkBoundary = "}}"
+
+		chunks := make(chan string, 128)
+		r.ReadRegions(strings.NewReader(input), func(chunk []byte, inside bool) {
+			chunks <- fmt.Sprintf("%t:%s", inside, chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{
+			"false:Hello ",
+			"true:{{name}}",
+			"false:, you ow",
+			"false:e ",
+			"true:{{amount}}",
+			"true:{{currency}}",
+			"false:. ",
+			"false:{{unclosed",
+		}, drain(chunks))
+	})
+
+	t.Run("with RequireBoundary", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.ChunkBoundaryStart = "{{"
+		r.ChunkBoundary = "}}"
+		r.RequireBoundary = true
+
+		chunks := make(chan string, 128)
+		r.ReadRegions(strings.NewReader(input), func(chunk []byte, inside bool) {
+			chunks <- fmt.Sprintf("%t:%s", inside, chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"true:{{name}}", "true:{{amount}}", "true:{{currency}}"}, drain(chunks))
+	})
+
+	t.Run("without ChunkBoundaryStart", func(t *testing.T) {
+		r := NewParallelReader()
+
+		assert.Panics(func() {
+			r.ReadRegions(strings.NewReader(input), func(chunk []byte, inside bool) {})
+		})
+	})
+}
+
+func TestSplitRegions(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewParallelReader()
+	r.ChunkSize = 6
+	r.ChunkBoundaryStart = "<<<"
+	r.ChunkBoundary = ">>>"
+
+	// A trailing "<<" could be the start of a region, so it's held back.
+	var inside bool
+	advance, token, err := r.splitRegions([]byte("abcd<<"), false, &inside)
+	assert.NoError(err)
+	assert.Equal(4, advance)
+	assert.Equal("abcd", string(token))
+	assert.False(inside)
+}
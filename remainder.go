@@ -0,0 +1,36 @@
+package rip
+
+import (
+	"bytes"
+	"io"
+)
+
+// Remainder returns a reader over the part of the stream that the most recent
+// read didn't get to, if it was stopped early (for example, by a
+// ReadContextWork callback returning Stop), so it can be handed to something
+// else to finish. It returns nil if the read wasn't stopped early.
+//
+// The remainder starts with the first chunk the scanner couldn't hand to a
+// worker, followed by whatever the scanner had buffered after it and the rest of
+// the underlying stream. Chunks that had already been handed to workers when
+// the read was stopped, but were then discarded unprocessed, aren't included;
+// nor are chunks held back by CoalesceFinal. Only reads that split on a
+// boundary, without ParallelScan, provide a remainder.
+//
+// A read cut short by MaxBytes also provides a remainder, which begins right
+// after the last byte delivered: with the partial record the limit cut off,
+// if any, followed by the rest of the underlying stream. This is for protocols
+// that switch to parsing a stream some other way after a bounded read.
+func (r *ParallelReader) Remainder() io.Reader {
+	return r.remainder
+}
+
+// stopped records the remainder of stream after a scan is stopped before it
+// could send token, with unread holding the data the scanner had buffered
+// after token. Both are copied, as the scanner's buffer isn't ours to keep.
+func (r *ParallelReader) stopped(token, unread []byte, stream io.Reader) {
+	buffered := make([]byte, 0, len(token)+len(unread))
+	buffered = append(buffered, token...)
+	buffered = append(buffered, unread...)
+	r.remainder = io.MultiReader(bytes.NewReader(buffered), stream)
+}
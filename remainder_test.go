@@ -0,0 +1,143 @@
+package rip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemainder(t *testing.T) {
+	assert := assert.New(t)
+
+	var input strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&input, "%03d\n", i)
+	}
+
+	t.Run("after a read is stopped early", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 1
+		r.ChunkSize = 4
+
+		r.ReadContextWork(context.Background(), strings.NewReader(input.String()), func(ctx context.Context, chunk []byte) error {
+			return Stop
+		})
+
+		if !assert.NotNil(r.Remainder()) {
+			return
+		}
+		rest, err := io.ReadAll(r.Remainder())
+		assert.NoError(err)
+
+		// The remainder is the tail of the input from the start of a record.
+		assert.NotEmpty(rest)
+		assert.Less(len(rest), input.Len())
+		assert.True(strings.HasSuffix(input.String(), string(rest)))
+		assert.Zero((input.Len() - len(rest)) % 4)
+	})
+
+	// stop reads input with r, stopping once the callback has seen 10 chunks,
+	// and returns the remainder.
+	stop := func(r *ParallelReader) string {
+		var calls int64
+		r.ReadContextWork(context.Background(), strings.NewReader(input.String()), func(ctx context.Context, chunk []byte) error {
+			if atomic.AddInt64(&calls, 1) >= 10 {
+				return Stop
+			}
+			return nil
+		})
+
+		if !assert.NotNil(r.Remainder()) {
+			return ""
+		}
+		rest, err := io.ReadAll(r.Remainder())
+		assert.NoError(err)
+		return string(rest)
+	}
+
+	t.Run("with NoCopy and PoisonBuffers", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 1
+		r.ChunkSize = 16
+		r.NoCopy = true
+		r.PoisonBuffers = true
+
+		rest := stop(r)
+		assert.Less(len(rest), input.Len()-40)
+		assert.True(strings.HasSuffix(input.String(), rest))
+		assert.Zero((input.Len() - len(rest)) % 4)
+	})
+
+	t.Run("with NoCopy and a ChunkBoundaryStart the same as ChunkBoundary", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 1
+		r.ChunkSize = 16
+		r.NoCopy = true
+		r.ChunkBoundary = "#"
+		r.ChunkBoundaryStart = "#"
+
+		// The first record starts partway into the scanner's buffer, and the
+		// read is stopped before it can be sent.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r.ReadContextWork(ctx, strings.NewReader("xx#000#001#002"), func(ctx context.Context, chunk []byte) error {
+			return nil
+		})
+
+		if !assert.NotNil(r.Remainder()) {
+			return
+		}
+		rest, err := io.ReadAll(r.Remainder())
+		assert.NoError(err)
+		assert.Equal("#000#001#002", string(rest))
+	})
+
+	t.Run("after a read is cut short by MaxBytes", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.MaxBytes = 10
+
+		stream := strings.NewReader("abc\ndef\nghi\njkl\n")
+		chunks := make(chan string, 128)
+		r.Read(stream, func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+
+		// The remainder picks up right after the last chunk delivered, with the
+		// partial record the limit cut off.
+		if !assert.NotNil(r.Remainder()) {
+			return
+		}
+		rest, err := io.ReadAll(r.Remainder())
+		assert.NoError(err)
+		assert.Equal("ghi\njkl\n", string(rest))
+	})
+
+	t.Run("after ReadFixed is cut short by MaxBytes", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.MaxBytes = 10
+
+		r.ReadFixed(strings.NewReader("0123456789abcdef"), func(chunk []byte) {})
+
+		rest, err := io.ReadAll(r.Remainder())
+		assert.NoError(err)
+		assert.Equal("abcdef", string(rest))
+	})
+
+	t.Run("after a read completes", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		r.Read(strings.NewReader(input.String()), func(chunk []byte) {})
+
+		assert.Nil(r.Remainder())
+	})
+}
@@ -0,0 +1,75 @@
+package rip
+
+import (
+	"io"
+	"math"
+)
+
+// ReadFromOffset is like Read, but begins reading source at startOffset and
+// passes each chunk's absolute offset in source to the callback. It's intended
+// for resuming a run that was interrupted: checkpoint the end offset of the last
+// chunk that was fully processed, then pass it here to pick up from there.
+// Setting Checkpoint keeps such a checkpoint as the read goes.
+//
+// To avoid starting in the middle of a record, reading begins just after the
+// first ChunkBoundary at or after startOffset. If startOffset is 0 or
+// immediately follows a ChunkBoundary, it's already the start of a record and
+// is used as is. If ChunkBoundaryStart is the same as ChunkBoundary, records
+// begin with the marker instead, so reading begins at the first marker at or
+// after startOffset, which the end offset of a chunk always is. Offsets count
+// any byte order mark StripBOM strips, so they're still positions in source.
+func (r *ParallelReader) ReadFromOffset(source io.ReaderAt, startOffset int64, work func(offset int64, chunk []byte)) error {
+	start, err := r.recordStart(source, startOffset)
+	if err != nil {
+		return err
+	}
+	if start < 0 {
+		return nil
+	}
+
+	r.checkpointBase = start
+	defer func() { r.checkpointBase = 0 }()
+
+	// Chunk offsets are counted after any byte order mark StripBOM strips.
+	base := start + r.bomAt(source, start)
+	stream := io.NewSectionReader(source, start, math.MaxInt64-start)
+	return r.run(stream, nil, func(c *chunk) {
+		work(base+c.offset, c.ReadableBytes())
+	})
+}
+
+// recordStart returns the offset of the first record in source that starts at
+// or after offset, or -1 if there isn't one.
+func (r *ParallelReader) recordStart(source io.ReaderAt, offset int64) (int64, error) {
+	boundary := []byte(r.ChunkBoundary)
+
+	// Begin searching len(boundary) bytes early, so a boundary that ends exactly
+	// at offset is found. A shared marker begins the record after it, so that's
+	// where the record starts.
+	early, skip := int64(len(boundary)), len(boundary)
+	if r.sharedMarker() {
+		early, skip = 0, 0
+	}
+	if offset <= 0 || len(boundary) == 0 {
+		return offset, nil
+	}
+	from := max(offset-early, 0)
+
+	buf := make([]byte, r.ChunkSize+len(boundary))
+	for {
+		n, err := source.ReadAt(buf, from)
+		if idx := r.index(buf[:n], boundary); idx > -1 {
+			return from + int64(idx+skip), nil
+		}
+		if err == io.EOF {
+			return -1, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		// Overlap successive reads by one byte less than the boundary, so a
+		// boundary straddling two reads isn't missed.
+		from += int64(n - len(boundary) + 1)
+	}
+}
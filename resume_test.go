@@ -0,0 +1,86 @@
+package rip
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadFromOffset(t *testing.T) {
+	assert := assert.New(t)
+
+	input := "abc\ndef\nghi\n"
+
+	read := func(startOffset int64) []string {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		chunks := make(chan string, 128)
+		err := r.ReadFromOffset(strings.NewReader(input), startOffset, func(offset int64, chunk []byte) {
+			chunks <- fmt.Sprintf("%d:%s", offset, chunk)
+		})
+		close(chunks)
+
+		assert.NoError(err)
+		return drain(chunks)
+	}
+
+	t.Run("from the start", func(t *testing.T) {
+		assert.ElementsMatch([]string{"0:abc\n", "4:def\n", "8:ghi\n"}, read(0))
+	})
+
+	t.Run("from the middle of a record", func(t *testing.T) {
+		assert.ElementsMatch([]string{"8:ghi\n"}, read(5))
+	})
+
+	t.Run("from the middle of the first record", func(t *testing.T) {
+		assert.ElementsMatch([]string{"4:def\n", "8:ghi\n"}, read(1))
+	})
+
+	t.Run("from exactly the start of a record", func(t *testing.T) {
+		assert.ElementsMatch([]string{"4:def\n", "8:ghi\n"}, read(4))
+	})
+
+	t.Run("from within the last record", func(t *testing.T) {
+		assert.Empty(read(9))
+	})
+
+	t.Run("from past the end", func(t *testing.T) {
+		assert.Empty(read(100))
+	})
+
+	t.Run("when ChunkBoundaryStart and ChunkBoundary are the same marker", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ChunkBoundaryStart = "#"
+		r.ChunkBoundary = "#"
+
+		chunks := make(chan string, 128)
+		err := r.ReadFromOffset(strings.NewReader("#abc#def#ghi#"), 4, func(offset int64, chunk []byte) {
+			chunks <- fmt.Sprintf("%d:%s", offset, chunk)
+		})
+		close(chunks)
+
+		assert.NoError(err)
+		assert.ElementsMatch([]string{"4:#def", "8:#ghi"}, drain(chunks))
+	})
+
+	t.Run("with StripBOM", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.StripBOM = true
+
+		source := "\xEF\xBB\xBFaaa\nbbb\n"
+		chunks := make(chan string, 128)
+		err := r.ReadFromOffset(strings.NewReader(source), 0, func(offset int64, chunk []byte) {
+			assert.Equal(string(chunk), source[offset:offset+int64(len(chunk))])
+			chunks <- fmt.Sprintf("%d:%s", offset, chunk)
+		})
+		close(chunks)
+
+		assert.NoError(err)
+		assert.ElementsMatch([]string{"3:aaa\n", "7:bbb\n"}, drain(chunks))
+	})
+}
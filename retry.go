@@ -0,0 +1,107 @@
+package rip
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// The delay before the first retry of a failed read, which doubles with each
+// consecutive retry.
+const retryReadBackoff = 10 * time.Millisecond
+
+// retryReader wraps a stream, retrying reads that fail with a retryable error.
+type retryReader struct {
+	stream      io.Reader
+	retries     int
+	isRetryable func(error) bool
+}
+
+// retrying wraps stream so that its reads are retried according to RetryRead
+// and IsRetryable, or returns it as is if RetryRead isn't set.
+func (r *ParallelReader) retrying(stream io.Reader) io.Reader {
+	if r.RetryRead <= 0 {
+		return stream
+	}
+
+	isRetryable := r.IsRetryable
+	if isRetryable == nil {
+		isRetryable = isTimeout
+	}
+	return &retryReader{stream: stream, retries: r.RetryRead, isRetryable: isRetryable}
+}
+
+func (rr *retryReader) Read(p []byte) (int, error) {
+	backoff := retryReadBackoff
+
+	for attempt := 0; ; attempt++ {
+		n, err := rr.stream.Read(p)
+		if err == nil || err == io.EOF || !rr.isRetryable(err) {
+			return n, err
+		}
+		// Hand back whatever was read before the error; the error will most
+		// likely recur on the next read if it's not transient after all.
+		if n > 0 {
+			return n, nil
+		}
+		if attempt == rr.retries {
+			return n, err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// The delay before the first retry of a failed callback, if RetryBackoff isn't
+// set.
+const retryWorkBackoff = 10 * time.Millisecond
+
+// retryingWork wraps work so that it's retried according to RetryWork,
+// RetryBackoff and IsRetryableWork, or returns it as is if RetryWork isn't set.
+func (r *ParallelReader) retryingWork(work func(ctx context.Context, chunk []byte) error) func(ctx context.Context, chunk []byte) error {
+	if r.RetryWork <= 0 {
+		return work
+	}
+
+	retries, initialBackoff, isRetryable := r.RetryWork, r.RetryBackoff, r.IsRetryableWork
+	if initialBackoff <= 0 {
+		initialBackoff = retryWorkBackoff
+	}
+	if isRetryable == nil {
+		isRetryable = func(error) bool { return true }
+	}
+
+	return func(ctx context.Context, chunk []byte) error {
+		backoff := initialBackoff
+
+		for attempt := 0; ; attempt++ {
+			err := work(ctx, chunk)
+			if err == nil || errors.Is(err, Stop) || attempt == retries || !isRetryable(err) {
+				return err
+			}
+
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// isTimeout is the default for IsRetryable, treating timeouts such as an
+// expired read deadline as transient.
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
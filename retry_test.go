@@ -0,0 +1,175 @@
+package rip
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryRead(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.RetryRead = 3
+
+		stream := &flakyReader{stream: strings.NewReader("abc\ndef\n"), failures: 2, err: os.ErrDeadlineExceeded}
+
+		chunks := make(chan string, 128)
+		err := r.read(stream, func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.NoError(err)
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+	})
+
+	t.Run("gives up after RetryRead attempts", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RetryRead = 2
+
+		stream := &flakyReader{stream: strings.NewReader("abc\n"), failures: 3, err: os.ErrDeadlineExceeded}
+
+		err := r.read(stream, func(chunk []byte) {})
+		assert.ErrorIs(err, os.ErrDeadlineExceeded)
+	})
+
+	t.Run("doesn't retry permanent errors", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RetryRead = 3
+
+		errPermanent := errors.New("permanent")
+		stream := &flakyReader{stream: strings.NewReader("abc\n"), failures: 1, err: errPermanent}
+
+		err := r.read(stream, func(chunk []byte) {})
+		assert.ErrorIs(err, errPermanent)
+	})
+
+	t.Run("with IsRetryable", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RetryRead = 1
+
+		errFlaky := errors.New("flaky")
+		r.IsRetryable = func(err error) bool {
+			return err == errFlaky
+		}
+		stream := &flakyReader{stream: strings.NewReader("abc\n"), failures: 1, err: errFlaky}
+
+		err := r.read(stream, func(chunk []byte) {})
+		assert.NoError(err)
+	})
+}
+
+// flakyReader fails with err the first few times it's read from.
+type flakyReader struct {
+	stream   *strings.Reader
+	failures int
+	err      error
+}
+
+func (f *flakyReader) Read(p []byte) (int, error) {
+	if f.failures > 0 {
+		f.failures--
+		return 0, f.err
+	}
+	return f.stream.Read(p)
+}
+
+func TestRetryWork(t *testing.T) {
+	assert := assert.New(t)
+
+	errFlaky := errors.New("rate limited")
+
+	t.Run("retries a failing callback with the same chunk", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.RetryWork = 3
+		r.RetryBackoff = time.Millisecond
+
+		var mu sync.Mutex
+		calls := map[string]int{}
+		err := r.ReadContextWork(context.Background(), strings.NewReader("abc\ndef\n"), func(ctx context.Context, chunk []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if calls[string(chunk)]++; calls[string(chunk)] <= 2 {
+				return errFlaky
+			}
+			return nil
+		})
+
+		assert.NoError(err)
+		assert.Equal(map[string]int{"abc\n": 3, "def\n": 3}, calls)
+	})
+
+	t.Run("gives up after RetryWork retries", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RetryWork = 2
+		r.RetryBackoff = time.Millisecond
+
+		var calls int64
+		err := r.ReadContextWork(context.Background(), strings.NewReader("abc\n"), func(ctx context.Context, chunk []byte) error {
+			atomic.AddInt64(&calls, 1)
+			return errFlaky
+		})
+
+		assert.ErrorIs(err, errFlaky)
+		assert.EqualValues(3, calls)
+	})
+
+	t.Run("with IsRetryableWork", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RetryWork = 2
+		r.RetryBackoff = time.Millisecond
+		errFatal := errors.New("bad record")
+		r.IsRetryableWork = func(err error) bool { return errors.Is(err, errFlaky) }
+
+		var calls int64
+		err := r.ReadContextWork(context.Background(), strings.NewReader("abc\n"), func(ctx context.Context, chunk []byte) error {
+			atomic.AddInt64(&calls, 1)
+			return errFatal
+		})
+
+		assert.ErrorIs(err, errFatal)
+		assert.EqualValues(1, calls)
+	})
+
+	t.Run("doesn't retry Stop", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RetryWork = 2
+
+		var calls int64
+		err := r.ReadContextWork(context.Background(), strings.NewReader("abc\n"), func(ctx context.Context, chunk []byte) error {
+			atomic.AddInt64(&calls, 1)
+			return Stop
+		})
+
+		assert.NoError(err)
+		assert.EqualValues(1, calls)
+	})
+
+	t.Run("stops waiting to retry when the run is cancelled", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RetryWork = 1
+		r.RetryBackoff = time.Hour
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := r.ReadContextWork(ctx, strings.NewReader("abc\n"), func(ctx context.Context, chunk []byte) error {
+			return errFlaky
+		})
+
+		assert.ErrorIs(err, context.DeadlineExceeded)
+		assert.Less(time.Since(start), time.Second)
+	})
+}
@@ -0,0 +1,92 @@
+package rip
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotSeekable is returned when Reverse is set but the stream can't be read
+// from any offset.
+var ErrNotSeekable = errors.New("rip: Reverse requires a seekable stream")
+
+// Where a chunk scanned by reverseScan lies in the stream, to read it back
+// later.
+type chunkSpan struct {
+	offset    int64
+	size      int
+	startLine int
+	final     bool
+}
+
+// reverseScan returns a producer for dispatch that scans stream for Reverse.
+// It finds the remainder of stream up front, from its current position to the
+// end, since dispatch may read from stream before calling it.
+func (r *ParallelReader) reverseScan(stream io.Reader) func(io.Reader, <-chan struct{}) error {
+	source, ok := seekable(stream)
+	if !ok {
+		return func(io.Reader, <-chan struct{}) error { return ErrNotSeekable }
+	}
+	start, err := source.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return func(io.Reader, <-chan struct{}) error { return err }
+	}
+
+	return func(_ io.Reader, done <-chan struct{}) error {
+		end, err := source.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		return r.scanReverse(io.NewSectionReader(source, start, end-start), done)
+	}
+}
+
+// scanReverse scans section like scan, but only to record where each chunk
+// lies, without copying any of them, and then reads the chunks back from last
+// to first and sends them in that order. They're indexed in the order they're
+// sent, so that the ordered APIs deliver them last to first too.
+func (r *ParallelReader) scanReverse(section *io.SectionReader, done <-chan struct{}) error {
+	var spans []chunkSpan
+	discard := r.discard
+	r.discard = true
+	r.scanned = func(c *chunk) {
+		spans = append(spans, chunkSpan{offset: c.offset, size: c.readableSize, startLine: c.startLine, final: c.final})
+	}
+	err := r.scan(section, done)
+	r.discard = discard
+	r.scanned = nil
+	if err != nil {
+		return err
+	}
+
+	// Scan only counts the chunks, so there's no need to read them back.
+	if discard {
+		for i := len(spans) - 1; i >= 0; i-- {
+			r.countChunk(&chunk{readableSize: spans[i].size})
+		}
+		return nil
+	}
+
+	completed := r.completed
+	r.completed = false
+	for i := len(spans) - 1; i >= 0; i-- {
+		span := spans[i]
+
+		var buf []byte
+		if span.size > r.ChunkSize {
+			buf = r.pool.alloc(span.size)
+		} else {
+			buf = r.pool.Borrow()
+		}
+		if n, err := section.ReadAt(buf[:span.size], span.offset); n < span.size {
+			r.pool.Return(buf)
+			return err
+		}
+
+		c := &chunk{buffer: buf, readableSize: span.size, index: len(spans) - 1 - i, offset: span.offset, startLine: span.startLine, final: span.final}
+		if !r.send(c, done) {
+			return nil
+		}
+	}
+	r.completed = completed
+	return nil
+}
@@ -0,0 +1,165 @@
+package rip
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverse(t *testing.T) {
+	assert := assert.New(t)
+
+	input := "one\ntwo\nthree\nfour\nfive\n"
+
+	t.Run("writes ordered output last to first", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.Reverse = true
+
+		var out bytes.Buffer
+		err := r.Transform(strings.NewReader(input), &out, func(chunk []byte) []byte {
+			return chunk
+		})
+
+		assert.NoError(err)
+		assert.Equal("five\nfour\nthree\ntwo\none\n", out.String())
+	})
+
+	t.Run("passes each chunk's position in the stream", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.Reverse = true
+
+		chunks := make(chan string, 128)
+		result := r.ReadMeta(strings.NewReader(input), func(info ChunkInfo) {
+			chunks <- fmt.Sprintf("%d@%d %t %q", info.Index, info.Offset, info.Final, info.Bytes)
+		})
+		close(chunks)
+
+		assert.True(result.Completed)
+		assert.ElementsMatch([]string{
+			`0@19 true "five\n"`,
+			`1@14 false "four\n"`,
+			`2@8 false "three\n"`,
+			`3@4 false "two\n"`,
+			`4@0 false "one\n"`,
+		}, drain(chunks))
+	})
+
+	t.Run("with line numbers", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.Reverse = true
+
+		lines := map[int]string{}
+		var mu sync.Mutex
+		r.ReadLineNumbered(strings.NewReader(input), func(startLine int, chunk []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines[startLine] = string(chunk)
+		})
+
+		assert.Equal(map[int]string{1: "one\ntwo\n", 3: "three\n", 4: "four\n", 5: "five\n"}, lines)
+	})
+
+	t.Run("from the current position of the stream", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.Reverse = true
+
+		stream := strings.NewReader(input)
+		stream.Seek(8, io.SeekStart)
+
+		var out bytes.Buffer
+		err := r.Transform(stream, &out, func(chunk []byte) []byte {
+			return chunk
+		})
+
+		assert.NoError(err)
+		assert.Equal("five\nfour\nthree\n", out.String())
+		assert.Zero(stream.Len())
+	})
+
+	t.Run("with Filter", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.Reverse = true
+		r.Filter = func(chunk []byte) bool { return !bytes.HasPrefix(chunk, []byte("t")) }
+
+		var out bytes.Buffer
+		err := r.Transform(strings.NewReader(input), &out, func(chunk []byte) []byte {
+			return chunk
+		})
+
+		assert.NoError(err)
+		assert.Equal("five\nfour\none\n", out.String())
+	})
+
+	t.Run("with a stream that isn't seekable", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Reverse = true
+
+		var out bytes.Buffer
+		err := r.Transform(io.MultiReader(strings.NewReader(input)), &out, func(chunk []byte) []byte {
+			return chunk
+		})
+
+		assert.ErrorIs(err, ErrNotSeekable)
+		assert.Zero(out.Len())
+	})
+
+	t.Run("with ReadBatches", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 1
+		r.ChunkSize = 2
+		r.BatchSize = 2
+		r.Reverse = true
+
+		var batches [][]string
+		result := r.ReadBatches(strings.NewReader("a\nb\nc\nd\n"), func(batch [][]byte) {
+			var chunks []string
+			for _, chunk := range batch {
+				chunks = append(chunks, string(chunk))
+			}
+			batches = append(batches, chunks)
+		})
+
+		assert.True(result.Completed)
+		assert.Equal([][]string{{"d\n", "c\n"}, {"b\n", "a\n"}}, batches)
+	})
+
+	t.Run("with ReadBatches and a stream that isn't seekable", func(t *testing.T) {
+		r := NewParallelReader()
+		r.RecoverPanics = true
+		r.Reverse = true
+
+		result := r.ReadBatches(io.MultiReader(strings.NewReader(input)), func(batch [][]byte) {})
+
+		assert.ErrorIs(result.Err, ErrNotSeekable)
+		assert.False(result.Completed)
+	})
+
+	t.Run("with Scan and a stream that isn't seekable", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Reverse = true
+
+		_, err := r.Scan(io.MultiReader(strings.NewReader(input)))
+		assert.ErrorIs(err, ErrNotSeekable)
+	})
+
+	t.Run("gathers the same stats with Scan", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.Reverse = true
+
+		stats, err := r.Scan(strings.NewReader(input))
+		assert.NoError(err)
+		assert.EqualValues(5, stats.Chunks)
+		assert.EqualValues(len(input), stats.Bytes)
+	})
+}
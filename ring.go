@@ -0,0 +1,191 @@
+package rip
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// tokenScanner is the part of bufio.Scanner that scan uses, so that ringScanner
+// can stand in for it.
+type tokenScanner interface {
+	Scan() bool
+	Bytes() []byte
+	Err() error
+}
+
+// maxEmptyReads is how many reads in a row may return no data and no error
+// before ringScanner gives up with io.ErrNoProgress, as bufio.Scanner does.
+const maxEmptyReads = 100
+
+// ringScanner is a scanner for NoCopy that reads stream directly into buffers
+// borrowed from the pool, rather than into a buffer of its own, so that a
+// chunk can be handed to a worker in the buffer it was read into. When the
+// buffer holding a token is taken, the data read after the token is copied to
+// the start of a fresh buffer, which is usually much less than a whole chunk.
+// A buffer that isn't taken, because its token was filtered out, is reused in
+// place.
+type ringScanner struct {
+	r      *ParallelReader
+	stream io.Reader
+	split  bufio.SplitFunc
+
+	// buf[:n] is the data read but not yet split, minus the current token.
+	buf []byte
+	n   int
+
+	token   []byte
+	advance int
+	final   bool
+
+	readErr error
+	err     error
+}
+
+// newRingScanner returns a ringScanner that splits stream like newScanner's
+// scanner, along with its position in stream.
+func (r *ParallelReader) newRingScanner(stream io.Reader) (*ringScanner, *scanPosition) {
+	split, pos := r.newSplit()
+	return &ringScanner{r: r, stream: stream, split: split, buf: r.pool.Borrow()}, pos
+}
+
+// Scan advances to the next token, returning false at the end of the stream or
+// on an error, like bufio.Scanner.Scan.
+func (s *ringScanner) Scan() bool {
+	s.consume()
+	s.token = nil
+	if s.final || s.err != nil {
+		return false
+	}
+
+	for empty := 0; ; {
+		atEOF := s.readErr != nil
+		if s.n > 0 || atEOF {
+			advance, token, err := s.split(s.buf[:s.n], atEOF)
+			if err == bufio.ErrFinalToken {
+				s.final = true
+				s.token, s.advance = token, advance
+				return token != nil
+			}
+			if err != nil {
+				s.err = err
+				return false
+			}
+			if advance < 0 || advance > s.n {
+				s.err = bufio.ErrNegativeAdvance
+				if advance > s.n {
+					s.err = bufio.ErrAdvanceTooFar
+				}
+				return false
+			}
+
+			s.advance = advance
+			if token != nil {
+				s.token = token
+				return true
+			}
+			if advance > 0 {
+				s.consume()
+				continue
+			}
+		}
+
+		if atEOF {
+			return false
+		}
+
+		if s.n == len(s.buf) && !s.grow() {
+			return false
+		}
+
+		n, err := s.stream.Read(s.buf[s.n:])
+		if n < 0 || n > len(s.buf)-s.n {
+			s.err = errors.New("rip: reader returned invalid count from Read")
+			return false
+		}
+		s.n += n
+		s.readErr = err
+		if n > 0 || err != nil {
+			empty = 0
+		} else if empty++; empty >= maxEmptyReads {
+			s.err = io.ErrNoProgress
+			return false
+		}
+	}
+}
+
+// grow replaces a full buffer with one twice the size, up to maxChunkSize, for
+// a record that doesn't fit. The full buffer goes back to the pool, and the
+// larger one is discarded by Return once its chunk has been processed.
+func (s *ringScanner) grow() bool {
+	max := s.r.maxChunkSize()
+	if len(s.buf) >= max {
+		s.err = bufio.ErrTooLong
+		return false
+	}
+
+	bigger := s.r.pool.alloc(min(2*len(s.buf), max))
+	copy(bigger, s.buf[:s.n])
+	s.r.pool.Return(s.buf)
+	s.buf = bigger
+	return true
+}
+
+// consume drops the current token and anything else the split function
+// advanced over, moving what's left to the start of the buffer.
+func (s *ringScanner) consume() {
+	if s.advance == 0 {
+		return
+	}
+	s.n = copy(s.buf, s.buf[s.advance:s.n])
+	s.advance = 0
+}
+
+// Bytes returns the current token, which is only valid until the next call to
+// Scan.
+func (s *ringScanner) Bytes() []byte {
+	return s.token
+}
+
+// Err returns the first error other than io.EOF that stopped the scan.
+func (s *ringScanner) Err() error {
+	if s.err == nil && s.readErr != io.EOF {
+		return s.readErr
+	}
+	return s.err
+}
+
+// take hands over the buffer holding the current token, with the token moved
+// to its start, if it wasn't there already. What was read after the token is
+// moved to the start of a new buffer straight away, since the rest of a taken
+// buffer is free for the worker to use (see ReadFull), so the scanner won't
+// touch the taken one again.
+func (s *ringScanner) take() []byte {
+	taken := s.buf
+	rest := taken[s.advance:s.n]
+
+	next := s.r.pool.Borrow()
+	if len(next) < len(rest) {
+		s.r.pool.Return(next)
+		next = s.r.pool.alloc(len(taken))
+	}
+	s.n = copy(next, rest)
+	s.buf, s.advance = next, 0
+
+	if start := cap(taken) - cap(s.token); start > 0 {
+		copy(taken, s.token)
+		s.token = taken[:len(s.token)]
+	}
+	return taken
+}
+
+// buffered returns what the scanner has read past the current token, which is
+// only valid until the next call to Scan.
+func (s *ringScanner) buffered() []byte {
+	return s.buf[s.advance:s.n]
+}
+
+// close returns the scanner's buffer to the pool.
+func (s *ringScanner) close() {
+	s.r.pool.Return(s.buf)
+}
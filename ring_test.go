@@ -0,0 +1,127 @@
+package rip
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoCopy(t *testing.T) {
+	assert := assert.New(t)
+
+	input := "a\nbcd\nefghij\n" + strings.Repeat("k", 100) + "\nlm\r\nnopq\r\n" + strings.Repeat("rstu\n", 200) + "unterminated"
+
+	// Scanned chunks, in order of their offsets.
+	scanned := func(r *ParallelReader, stream io.Reader) ([]string, error) {
+		var mu sync.Mutex
+		chunks := map[int64]string{}
+		err := r.run(stream, nil, func(c *chunk) {
+			mu.Lock()
+			defer mu.Unlock()
+			chunks[c.offset] = string(c.ReadableBytes())
+		})
+
+		offsets := make([]int64, 0, len(chunks))
+		for offset := range chunks {
+			offsets = append(offsets, offset)
+		}
+		sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+		var ordered []string
+		for _, offset := range offsets {
+			ordered = append(ordered, chunks[offset])
+		}
+		return ordered, err
+	}
+
+	configs := map[string]func(r *ParallelReader){
+		"with the defaults":    func(r *ParallelReader) {},
+		"with ChunkBoundaries": func(r *ParallelReader) { r.ChunkBoundaries = []string{"\n", "\r\n"} },
+		"with Filter":          func(r *ParallelReader) { r.Filter = func(chunk []byte) bool { return chunk[0] != 'r' } },
+		"with FirstBoundary":   func(r *ParallelReader) { r.FirstBoundary = "j\n" },
+		"with CoalesceFinal":   func(r *ParallelReader) { r.CoalesceFinal = true; r.MinChunkSize = 16 },
+		"with RequireBoundary": func(r *ParallelReader) { r.RequireBoundary = true },
+		"without a boundary":   func(r *ParallelReader) { r.ChunkBoundary = "" },
+	}
+
+	for name, configure := range configs {
+		t.Run(name, func(t *testing.T) {
+			r := NewParallelReader()
+			r.ChunkSize = 32
+			configure(r)
+
+			expected, err := scanned(r, strings.NewReader(input))
+			assert.NoError(err)
+
+			r.NoCopy = true
+			actual, err := scanned(r, strings.NewReader(input))
+			assert.NoError(err)
+			assert.Equal(expected, actual)
+
+			actual, err = scanned(r, oneByteReader(input))
+			assert.NoError(err)
+			assert.Equal(expected, actual)
+		})
+	}
+
+	t.Run("with ReadFull scratch space", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 32
+		r.NoCopy = true
+
+		var mu sync.Mutex
+		var chunks []string
+		r.ReadFull(strings.NewReader(input), func(buf []byte, readableSize int) {
+			// Scribbling over the rest of the buffer mustn't affect later chunks.
+			for i := readableSize; i < len(buf); i++ {
+				buf[i] = '!'
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			chunks = append(chunks, string(buf[:readableSize]))
+		})
+
+		sort.Strings(chunks)
+		joined := strings.Join(chunks, "")
+		assert.NotContains(joined, "!")
+		assert.Len(joined, len(input))
+	})
+
+	t.Run("with a record longer than MaxChunkSize", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 32
+		r.MaxChunkSize = 64
+		r.NoCopy = true
+
+		_, err := scanned(r, strings.NewReader(input))
+		assert.ErrorIs(err, bufio.ErrTooLong)
+	})
+
+	t.Run("with a stream error", func(t *testing.T) {
+		r := NewParallelReader()
+		r.NoCopy = true
+
+		_, err := scanned(r, io.MultiReader(strings.NewReader(input), iotest.ErrReader(errTest)))
+		assert.ErrorIs(err, errTest)
+	})
+
+	t.Run("returns every buffer to the pool", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 32
+		r.NoCopy = true
+
+		var borrowed, returned int64
+		var mu sync.Mutex
+		r.Pool().OnBorrow = func([]byte) { mu.Lock(); borrowed++; mu.Unlock() }
+		r.Pool().OnReturn = func([]byte) { mu.Lock(); returned++; mu.Unlock() }
+
+		r.Read(strings.NewReader(input), func(chunk []byte) {})
+		assert.Equal(borrowed, returned)
+	})
+}
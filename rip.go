@@ -3,9 +3,13 @@ package rip
 import (
 	"bufio"
 	"bytes"
+	"hash"
 	"io"
 	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
 )
 
 type ParallelReader struct {
@@ -14,15 +18,489 @@ type ParallelReader struct {
 	ChunkBoundary      string
 	ChunkBoundaryStart string
 	RequireBoundary    bool
-	chunks             chan *chunk
-	pool               *Pool
+
+	// With RequireBoundary, any data at the end of the stream that isn't
+	// terminated by a ChunkBoundary is dropped. If set, OnDropped is called with
+	// that data before it's discarded; it must not be retained after OnDropped
+	// returns. The number of bytes dropped is also reported in Stats.
+	OnDropped func(data []byte)
+
+	// ChunkBoundaries generalizes ChunkBoundary to several candidates, any of which
+	// can end a chunk. When set, it's used instead of ChunkBoundary, and chunks
+	// are split at the rightmost match of any candidate.
+	ChunkBoundaries []string
+
+	// When the input ends exactly on a ChunkBoundary, the scanner produces a
+	// final, empty chunk. By default it's discarded; set EmitEmptyFinalChunk to
+	// deliver it to the callback. This only applies when splitting on a boundary.
+	EmitEmptyFinalChunk bool
+
+	// The number of idle buffers kept for reuse, which defaults to Concurrency
+	// (times BatchSize, if set) plus Prefetch when zero, or twice that with
+	// NoCopy. The pool is kept between reads, so buffers allocated by one read
+	// are reused by the next.
+	PoolSize int
+
+	// If set, ReadFile and ReadFiles read each file through a bufio.Reader of
+	// this size. Unlike ChunkSize, which sets the size of the chunks passed to
+	// callbacks, this sets the size of reads from the file itself. It's worth
+	// setting (to around 1 MiB) when ChunkSize is small and per-read syscall
+	// overhead is high, as it can be on Windows; with the default ChunkSize,
+	// reads are already large enough that it makes little difference.
+	ReadBufferSize int
+
+	// When set, and the stream passed to Read or ReadFile is seekable (i.e. it
+	// implements io.ReaderAt and io.Seeker, like *os.File), the stream is split
+	// into Concurrency sections which are scanned in parallel, rather than
+	// being scanned by a single goroutine. Each section begins at the start of a
+	// record, so a record straddling the edge of two sections is only read by
+	// the first. This removes the scanner as a bottleneck on large files, but
+	// means chunks no longer correspond to a single sequential scan, and only
+	// ChunkBoundary (not ChunkBoundaries) is used to find where sections begin.
+	ParallelScan bool
+
+	// When set, chunks are delivered from the end of the stream back to the
+	// start, as for processing a log newest first. The stream has to be
+	// seekable (see ParallelScan); otherwise the read fails with
+	// ErrNotSeekable. It's scanned once to find where every chunk lies, without
+	// copying any of them, and then each chunk is read back with ReadAt as it's
+	// dispatched, last first. Workers still process chunks in parallel, and
+	// Transform and the other ordered APIs write them last to first. The
+	// position of every chunk is held until the read ends, about 32 bytes per
+	// chunk, or 1 MiB for a 2 GiB file at the default ChunkSize. It applies to
+	// the methods that scan for ChunkBoundary, but not ReadFixed or
+	// ReadRegions. It disables ParallelScan, and StripBOM, MaxBytes, RetryRead,
+	// WholeHash and RejectBinary don't apply to it.
+	Reverse bool
+
+	// When set, the size of chunks is adjusted to contain roughly
+	// RecordsPerChunk records each (1024 by default), based on the average size
+	// of the records seen during the first few thousand. Chunks never exceed
+	// ChunkSize, so it should be set high enough to allow for the largest
+	// chunks wanted. Calibration starts from small 4 KiB chunks, so early
+	// chunks may be uneven compared to later ones. AutoChunkSize has no effect
+	// with ParallelScan.
+	AutoChunkSize   bool
+	RecordsPerChunk int
+
+	// When set, Stats also reports how long the scanner and workers spent
+	// waiting on each other. This adds a couple of calls to time.Now per chunk.
+	Profile bool
+
+	// When set, each worker goroutine is locked to its own OS thread for the
+	// duration of a read with runtime.LockOSThread. This suits callbacks that
+	// call into cgo or otherwise block their thread, at the cost of an OS thread
+	// per worker and less freedom for the Go scheduler.
+	LockWorkerThreads bool
+
+	// A debugging aid for callbacks suspected of keeping chunks after they
+	// return, which the pool may then reuse for later chunks behind their back.
+	// When set, every buffer returned to the pool is overwritten with 0xDE
+	// bytes before it can be reused, so a callback that reads a chunk it kept
+	// sees obviously corrupt data rather than a subtly wrong record. Chunks
+	// that aren't in pooled buffers, like those longer than ChunkSize and those
+	// scanned with ParallelScan, aren't poisoned. It costs a write of every
+	// chunk, so it's off by default.
+	PoisonBuffers bool
+
+	// If set, every chunk is delivered in a buffer that starts at a multiple
+	// of BufferAlignment bytes, like 64 for AVX-512 loads, for callbacks that
+	// use SIMD through assembly or unsafe. Buffers are over-allocated by up to
+	// BufferAlignment-1 bytes and sliced to start at the aligned address. It's
+	// only the start of each chunk that's aligned, not the records within it.
+	// It disables ParallelScan, whose chunks are slices of a scanner's buffer.
+	BufferAlignment int
+
+	// The maximum number of completed chunks that Transform and similar ordered
+	// APIs will hold while waiting for an earlier, slower chunk to finish, which
+	// defaults to 4 * Concurrency when zero. Once it's reached, workers block
+	// until the slow chunk completes. A larger value lets fast workers keep going
+	// for longer around slow chunks, at the cost of more memory held.
+	//
+	// Workers already share one queue of chunks, so an idle worker always picks
+	// up the next pending chunk; what holds them up when chunk costs are uneven
+	// is this limit. It helps to raise it when a slow chunk can take longer than
+	// the other workers need to get through the window: in
+	// BenchmarkTransformUnevenCost, where one chunk in 64 takes 20 times as long
+	// as the rest, a window of 64 rather than the default 16 for four workers
+	// made the transform about 1.3x as fast. When the slow chunks are rarely
+	// that slow, a larger window makes no difference.
+	MaxReorderBuffer int
+
+	// When set, Transform and the other ordered APIs flush their writer this
+	// often while anything written to it hasn't been flushed, and again once
+	// they're done, if it has a Flush method (like bufio.Writer, gzip.Writer and
+	// http.ResponseWriter do). Output is already written as soon as the next
+	// chunk in order is ready, so this doesn't reorder anything; it bounds how
+	// long output can sit in a buffering writer, for streaming.
+	FlushInterval time.Duration
+
+	// By default, once ReadContextWork is stopped, it waits for the callbacks
+	// already running to return, however long they take. If ShutdownGrace is
+	// set, it waits at most this long before returning an error that matches
+	// ErrShutdownIncomplete, leaving any callbacks still running to finish on
+	// their own goroutines, with their chunks, which aren't reused. This bounds
+	// how long a service takes to shut down when a callback is slow or stuck.
+	// Callbacks that run on the calling goroutine, as with ParallelScan or an
+	// input under MinParallelSize, can't be left behind this way.
+	ShutdownGrace time.Duration
+
+	// If set, the offset in the stream up to which every chunk has been
+	// processed is written to Checkpoint as a line of decimal digits every
+	// CheckpointInterval (a second by default) while it advances, and once
+	// more when the read ends, so that a batch job that crashes can resume
+	// from the last one with ReadCheckpoint and ReadFromOffset. Chunks finish
+	// out of order, so a chunk that's been processed only counts once every
+	// chunk before it has been too; there are never gaps before the offset
+	// written. A chunk whose callback fails, or panics with RecoverPanics set,
+	// or that's skipped once the run has been stopped, isn't processed, and the
+	// offset stops before it for the rest of the read. Offsets from
+	// ReadFromOffset are in its source rather than the section it reads. An
+	// error writing a checkpoint fails the read, but only once it's finished.
+	// It disables ParallelScan, and doesn't apply to Reverse.
+	Checkpoint         io.Writer
+	CheckpointInterval time.Duration
+
+	// By default, any error reading the input stream ends the read. If
+	// RetryRead is set, a read that fails with an error IsRetryable reports as
+	// transient is instead retried up to RetryRead times, with an exponential
+	// backoff starting at 10ms. When IsRetryable is nil, timeouts (such as
+	// os.ErrDeadlineExceeded from a net.Conn with a read deadline) are
+	// retryable; note that an expired deadline must be extended for a retry
+	// to succeed, which IsRetryable is a convenient place to do. Retries don't
+	// apply to ParallelScan.
+	RetryRead   int
+	IsRetryable func(err error) bool
+
+	// By default, an error returned by a ReadContextWork callback stops the
+	// run. If RetryWork is set, a callback that fails with an error
+	// IsRetryableWork reports as transient, like a downstream's rate limit, is
+	// instead called again with the same chunk up to RetryWork times, without
+	// reading it again, after a backoff that starts at RetryBackoff (10ms when
+	// it's zero) and doubles with each retry. When IsRetryableWork is nil, any
+	// error but Stop is retryable. The backoff is cut short, and the error
+	// returned, if the run is stopped meanwhile. The chunk stays in its buffer
+	// during retries just as it does while the callback runs, so retries hold
+	// up their worker but don't call for a larger PoolSize.
+	RetryWork       int
+	RetryBackoff    time.Duration
+	IsRetryableWork func(err error) bool
+
+	// If set, Transform and ExpandTransform write OutputSeparator between
+	// successive outputs (but not after the last), skipping empty outputs.
+	OutputSeparator []byte
+
+	// When set, a UTF-8 or UTF-16 byte order mark at the start of the stream is
+	// removed before it's split into chunks, so it doesn't end up in the first
+	// record. If TranscodeUTF16 is also set, a stream that starts with a UTF-16
+	// BOM is decoded to UTF-8; otherwise only the BOM itself is removed. Chunk
+	// offsets are relative to the stream after the BOM. Neither applies to
+	// ParallelScan.
+	StripBOM       bool
+	TranscodeUTF16 bool
+
+	// When set, everything up to and including the first ChunkBoundary (or the
+	// first of ChunkBoundaries) is discarded before the stream is split into
+	// chunks, so that a stream that may begin partway through a record, like
+	// the tail of a log that's being rotated, never delivers the partial record
+	// as its first chunk. If there's no boundary at all, nothing is delivered.
+	// Chunk offsets are relative to the stream after what was skipped. With a
+	// ChunkBoundaryStart the same as ChunkBoundary, what's before the first
+	// marker is always skipped, so it has no effect. It disables ParallelScan,
+	// and doesn't apply to BoundaryFunc or Reverse. To start a seekable source
+	// at an arbitrary offset, see ReadFromOffset.
+	SkipToFirstBoundary bool
+
+	// When set, a stream that looks like binary data rather than text fails
+	// the read with ErrBinaryInput before any chunk is delivered, to guard
+	// tools that process text against being pointed at the wrong file. The
+	// first 8 KiB of the stream are sampled, and taken for binary if they
+	// contain a NUL byte, or if more than 30% of them are control characters
+	// other than whitespace, backspace and escape. This errs towards text:
+	// bytes from 0x80 up are never counted, so UTF-8 always gets through, and
+	// neither are the bytes of ChunkBoundary or ChunkBoundaries, so records
+	// separated by NUL do too. A UTF-16 stream looks binary unless StripBOM and
+	// TranscodeUTF16 are set.
+	RejectBinary bool
+
+	// If set, the first record of a stream ends with FirstBoundary rather than
+	// ChunkBoundary, and is delivered as a chunk of its own, for formats that
+	// begin with a header block terminated differently from the records after
+	// it (such as by a blank line). ChunkBoundary takes over from there. Setting
+	// it disables ParallelScan, and calling ScanChunksWithBoundary directly
+	// doesn't take it into account.
+	FirstBoundary string
+
+	// If set, BoundaryFunc finds the ends of records in place of ChunkBoundary,
+	// ChunkBoundaries and ChunkBoundaryStart, for formats whose records can only
+	// be told apart by their content, like balanced brackets. It's called with
+	// data starting at the beginning of a record, and returns the index just
+	// past the end of that record, or -1 if data doesn't hold all of it yet,
+	// in which case it's called again once more is buffered (up to
+	// MaxChunkSize). Records are packed into chunks of up to ChunkSize as they
+	// are with ChunkBoundary, and at EOF, what's left after the last record is
+	// the final chunk, unless RequireBoundary is set. It's called on the
+	// scanning goroutine. Setting it disables ParallelScan, and MaxLineLength
+	// doesn't apply.
+	BoundaryFunc func(data []byte, atEOF bool) int
+
+	// The largest chunk the scanner will buffer while looking for a
+	// ChunkBoundary, for records that don't fit in ChunkSize. A chunk that ends
+	// up longer than ChunkSize this way gets a buffer allocated just for it
+	// rather than one from the pool; a record longer than MaxChunkSize fails
+	// the read with bufio.ErrTooLong. Defaults to 16 times ChunkSize when 0.
+	MaxChunkSize int
+
+	// When set, each chunk is delivered with up to Lookahead of the bytes that
+	// follow it appended, for parsers that can only tell a record has ended by
+	// seeing the start of the next one. The scanner still advances only to the
+	// end of the chunk, so the lookahead bytes appear again at the start of the
+	// next chunk, and Stats and Metrics count them in both. Only the final
+	// chunk, or one followed by less than Lookahead before the end of the
+	// stream, has less. Records are packed into chunks of up to ChunkSize less
+	// Lookahead, to leave room for it in a pooled buffer, so Lookahead should
+	// be well under ChunkSize. Filter sees chunks without their lookahead. It
+	// applies to the methods that scan for ChunkBoundary, and disables
+	// ParallelScan and NoCopy.
+	Lookahead int
+
+	// When set, and the final chunk of a stream is smaller than MinChunkSize,
+	// it's appended to the chunk before it rather than being delivered on its
+	// own. This smooths out batch sizes for consumers that are sensitive to a
+	// tiny final batch, but delays delivery of the second-to-last chunk until
+	// the end of the stream is reached, and the merged chunk can be larger than
+	// ChunkSize. This only applies when splitting on a boundary.
+	CoalesceFinal bool
+	MinChunkSize  int
+
+	// When set, every "\r\n" and lone "\r" in a chunk is rewritten to "\n", and
+	// the line ending at the end of the chunk is removed, so that a chunk is
+	// just its lines joined by "\n". This is meant to be used with LineEndings
+	// as ChunkBoundaries; see LineMode. Chunks are shortened in place, so
+	// ReadFull's readableSize reflects the rewrite. It doesn't apply to
+	// ReadFixed.
+	NormalizeLineEndings bool
+
+	// If set, no more than MaxBytes are read from a stream, as a safeguard
+	// against untrusted input that never ends. When splitting on a boundary,
+	// reading stops at the last ChunkBoundary within the limit: a partial
+	// record cut off by the limit isn't delivered, but is counted in
+	// DroppedBytes and passed to OnDropped like with RequireBoundary. ReadFixed
+	// delivers everything up to the limit. A read that hits the limit doesn't
+	// count as completed, and fails with ErrMaxBytes if FailOnMaxBytes is set.
+	// Remainder then returns the rest of the stream, starting with the partial
+	// record, so it can be read some other way. Setting MaxBytes disables
+	// ParallelScan.
+	MaxBytes       int64
+	FailOnMaxBytes bool
+
+	// If set, the number of records stream is expected to hold, as listed in a
+	// manifest, so that a truncated or duplicated file is caught: a read that
+	// reaches the end of the stream having seen a different number fails with
+	// a *RecordCountError, which matches ErrRecordCountMismatch, once every
+	// chunk has been processed. Records are counted by their boundaries (or by
+	// BoundaryFunc), along with an unterminated final record, including those
+	// in chunks skipped by Filter or sampling; with ReadFixed, or without a
+	// boundary, each chunk counts as a record. A read that's stopped early
+	// isn't checked. The count is in Stats.Records.
+	ExpectedRecords int64
+
+	// The number of chunks ReadBatches passes to each call of its callback. Each
+	// worker holds on to a batch's buffers until its callback returns, so when
+	// PoolSize isn't set the pool holds BatchSize buffers per worker.
+	BatchSize int
+
+	// If set, WholeHash is reset at the start of each read, then updated with
+	// every byte read from the stream, in order, and its sum is reported in
+	// Stats. This verifies the whole input in the same pass that processes it,
+	// but hashing is serial work on the scanning goroutine, proportional to the
+	// size of the input. The sum only covers what was read, so it's only
+	// meaningful for reads that ran to completion. Setting it disables
+	// ParallelScan.
+	WholeHash hash.Hash
+
+	// The number of chunks ReadFixed may read ahead of the workers, on a
+	// goroutine of its own, beyond the one per worker that are always queued.
+	// This only helps when reads or work take an uneven amount of time, letting
+	// a burst of fast reads get ahead while the workers are busy, to make up for
+	// slow reads later; when both are steady, throughput is limited by the
+	// slower of the two however deep the queue. See BenchmarkReadFixedPrefetch,
+	// where reading a source that stalls for 4ms on every 16th read with 4 workers
+	// that each take 200µs per chunk, a Prefetch of 16 was about 1.5x as fast
+	// as none. The pool holds Prefetch more buffers to match. Zero (the default)
+	// disables prefetching.
+	Prefetch int
+
+	// When set, the scanner reads the stream directly into buffers from the
+	// pool, which holds twice as many as usual (2*Concurrency by default) so
+	// it can cycle through them while the workers hold the rest, and each chunk
+	// is handed to a worker in the buffer it was read into rather than being
+	// copied out of the scanner's own. Only the partial record read after each
+	// chunk is copied into the next buffer. A buffer isn't reused until the
+	// worker it was handed to has returned it to the pool, so this is as safe
+	// as the default. It applies to the methods that scan for ChunkBoundary,
+	// but not ReadRegions. In BenchmarkReadNoCopy, which reads 64 KiB chunks of
+	// short lines with the same PoolSize either way, it was about 1.3x as fast,
+	// and saved allocating the scanner's own buffer on every read.
+	NoCopy bool
+
+	// When set, Read first checks whether the stream is smaller than
+	// MinParallelSize bytes, by its Len method if it has one (as bytes.Reader
+	// and strings.Reader do), by seeking if it's a regular file, or else by
+	// buffering up to MinParallelSize bytes of it. A smaller stream is scanned
+	// and processed on the calling goroutine, without starting any workers,
+	// which saves the overhead of a parallel read on inputs too small to
+	// benefit, like the many tiny requests of a server. It's still split into
+	// chunks as usual, so an input no larger than ChunkSize is processed as a
+	// single chunk. In BenchmarkReadSmall, which reads 704 bytes with four
+	// workers, a read took about two thirds as long.
+	MinParallelSize int
+
+	// When set, a record longer than MaxLineLength bytes, not counting its
+	// boundary, fails the read with ErrMaxLineLength as soon as that many bytes
+	// of it have been buffered, rather than being buffered up to MaxChunkSize.
+	// This is a guard against untrusted input built to exhaust memory with one
+	// endless record, and limits individual records whatever the chunk sizes.
+	// Checking each record adds a search for every boundary to the scan. It
+	// doesn't apply to ReadFixed or ReadRegions, or without a ChunkBoundary.
+	MaxLineLength int
+
+	// When set, ChunkBoundary, ChunkBoundaries, ChunkBoundaryStart and
+	// FirstBoundary match regardless of the case of any ASCII letters in them,
+	// so that a boundary of "</item>" also matches "</ITEM>". Chunks are still
+	// delivered exactly as read. Records, which isn't a method, always matches
+	// exactly.
+	CaseInsensitiveBoundary bool
+
+	// When set, ReadMeta counts lines to fill in ChunkInfo.StartLine, at the
+	// cost of some serial work on the scanning goroutine (see
+	// ReadLineNumbered).
+	CountLines bool
+
+	// If set, chunks waiting for a worker are handed out highest priority first
+	// (in the order they were scanned, for equal priorities) rather than first
+	// in, first out. Priority is called with each chunk as it's taken off the
+	// scanner's queue by a goroutine that keeps a heap of them for the workers.
+	// Only chunks that are waiting can be reordered, so this has no effect
+	// unless the scanner is getting ahead of the workers. It adds overhead to
+	// every chunk, and lets the scanner get about twice as far ahead, since up
+	// to Concurrency chunks can wait in the heap as well as in the queue.
+	// It isn't supported by ParallelScan, which is ignored when it's set, and
+	// it's ignored by the APIs that deliver results in order, like Transform
+	// and PipeOrdered, whose workers would otherwise end up waiting for a chunk
+	// it held back.
+	Priority func(chunk []byte) int
+
+	// When set, a panic in a callback running on a worker goroutine is
+	// recovered rather than crashing the program, and the read is stopped as
+	// soon as possible. The panic is reported as a *PanicError, taking
+	// precedence over any error reading the stream: methods that return a
+	// Result report it in Result.Err, and then never panic themselves, and
+	// methods that return an error return it. ReadGrouped and CollectFirst,
+	// which do neither, still panic with it, but on the calling goroutine,
+	// where it can be recovered. Panics in callbacks that run on the scanning
+	// goroutine, like Filter, aren't affected.
+	RecoverPanics bool
+
+	// If set, Filter is called with each chunk before it's dispatched, and
+	// chunks for which it returns false are skipped without being copied or
+	// handed to a worker. This is cheaper than discarding unwanted chunks in the
+	// callback, but Filter runs serially on the scanning goroutine (or on each
+	// section's goroutine with ParallelScan), so it should be quick, and it sees
+	// chunks before NormalizeLineEndings is applied. Skipped chunks don't count
+	// towards Stats. It doesn't apply to ReadFixed.
+	Filter func(chunk []byte) bool
+
+	// If SampleEvery is set, only every SampleEvery-th chunk, starting with the
+	// first, is delivered. If SampleRate is set, each chunk is instead delivered
+	// with that probability, using random numbers seeded by SampleSeed, so the
+	// same seed picks the same chunks from the same input. If both are set, a
+	// chunk must be picked by both. This is for quick approximate analysis of a
+	// large input: like chunks skipped by Filter, which applies first, skipped
+	// chunks are dropped on the scanning goroutine without being copied, and
+	// don't count towards Stats. Sampling is per chunk, so to sample individual
+	// records, ChunkSize should be small enough for a chunk to hold just one.
+	// With ParallelScan, each section is sampled separately. It doesn't apply to
+	// ReadFixed.
+	SampleEvery int
+	SampleRate  float64
+	SampleSeed  int64
+
+	// If set, DebugSplit is called after each call to the split function the
+	// scanner uses (ScanChunksWithBoundary, unless FirstBoundary is pending),
+	// with the length of the data it was given, whether it was at EOF, and the
+	// advance and length of the token it returned, or -1 if it returned no
+	// token and needs more data. This shows how chunks are cut at the edges of
+	// the scanner's buffer, for diagnosing unexpected chunks. It's called on the
+	// scanning goroutine, or concurrently on each section's goroutine with
+	// ParallelScan. It doesn't apply to ReadFixed or ReadRegions.
+	DebugSplit func(dataLen int, atEOF bool, advance int, tokenLen int)
+
+	// If set, Middleware is used to wrap the callback, for cross-cutting
+	// concerns like tracing spans or timing each call without changing the
+	// callback itself, as with HTTP middleware. Each worker calls it once per
+	// read to compose its own chain; the function it returns is then called
+	// with each chunk in place of the callback, and should call next with the
+	// same chunk to process it, or not at all to skip it; the ordered APIs,
+	// like Transform and PipeOrdered, produce no output for a skipped chunk.
+	// It sees chunks before NormalizeLineEndings is applied. With
+	// ReadBatches and a BatchSize above 1, it wraps each call with a whole
+	// batch, and is passed a nil chunk. Several middlewares can be combined
+	// with Chain.
+	Middleware func(next func(chunk []byte)) func(chunk []byte)
+
+	limit         *limitReader
+	panics        *panicState
+	remainder     io.Reader
+	chunks        chan *chunk
+	queue         chan *chunk
+	pool          *Pool
+	stats         *Stats
+	processed     progress
+	poolCounts    *poolCounts
+	busy          int64
+	completed     bool
+	countLines    bool
+	markFinal     bool
+	discard       bool
+	scanned       func(c *chunk)
+	graceful      bool
+	serial        bool
+	inline        func(c *chunk)
+	autoChunkSize int
+	calibration   struct{ records, bytes int }
+	// The run's checkpoints, and the offset in the source that ReadFromOffset
+	// started the stream at, which they're counted from.
+	checkpoints    *checkpointer
+	checkpointBase int64
+	// If not nil, called by a worker for each chunk that Middleware skipped, so
+	// that the ordered APIs don't wait forever for its result.
+	skipped func(c *chunk)
+	// Whether the run is one of the ordered APIs', which ignore Priority.
+	ordered bool
 }
 
+// AutoChunkSize starts with small chunks, so that the first chunk doesn't take
+// a large share of a small input, then observes autoCalibrationRecords records
+// before settling on a chunk size. It aims for defaultRecordsPerChunk records in
+// each chunk unless RecordsPerChunk is set.
+const (
+	autoInitialChunkSize   = 4 << 10 // 4 KiB
+	autoCalibrationRecords = 4096
+	defaultRecordsPerChunk = 1024
+)
+
+// Unless MaxChunkSize is set, the scanner may buffer up to this many times
+// ChunkSize to find the end of a long record.
+const defaultMaxChunkSizeFactor = 16
+
 func NewParallelReader() *ParallelReader {
 	r := new(ParallelReader)
 	r.Concurrency = runtime.NumCPU()
 	r.ChunkBoundary = "\n"
 	r.ChunkSize = 1 << 16 // 64 KiB
+	r.poolCounts = new(poolCounts)
+	r.stats = new(Stats)
 
 	return r
 }
@@ -30,118 +508,834 @@ func NewParallelReader() *ParallelReader {
 // Read takes an input io.Reader stream and calls the passed callback from a
 // pool of goroutines, once per chunk. Your callback could receive chunks in any
 // order.
-func (r *ParallelReader) Read(stream io.Reader, work func(chunk []byte)) {
-	r.pool = NewPool(r.Concurrency, r.ChunkSize)
+func (r *ParallelReader) Read(stream io.Reader, work func(chunk []byte)) Result {
+	return r.finish(r.read(stream, work))
+}
+
+// parallelScan reports whether ParallelScan is set and none of the settings
+// that it can't support are.
+func (r *ParallelReader) parallelScan() bool {
+	return r.ParallelScan && r.MaxBytes <= 0 && r.FirstBoundary == "" && r.WholeHash == nil && r.Priority == nil && !r.sharedMarker() && r.BoundaryFunc == nil && !r.Reverse && !r.SkipToFirstBoundary && r.Lookahead <= 0 && r.BufferAlignment <= 1 && r.Checkpoint == nil
+}
+
+// hashing wraps stream to update WholeHash, if it's set, with everything read
+// from it.
+func (r *ParallelReader) hashing(stream io.Reader) io.Reader {
+	if r.WholeHash == nil {
+		return stream
+	}
+	r.WholeHash.Reset()
+	return io.TeeReader(stream, r.WholeHash)
+}
+
+// read is Read, but returns errors from the stream instead of panicking.
+func (r *ParallelReader) read(stream io.Reader, work func(chunk []byte)) error {
+	if r.MinParallelSize > 0 {
+		var small bool
+		if stream, small = r.measure(stream); small {
+			r.serial = true
+			defer func() { r.serial = false }()
+		}
+	}
+
+	if r.parallelScan() && !r.serial {
+		if source, ok := seekable(stream); ok {
+			return r.readSeekerParallel(source, func(c *chunk) {
+				work(c.ReadableBytes())
+			})
+		}
+	}
+
+	return r.run(stream, nil, func(c *chunk) {
+		work(c.ReadableBytes())
+	})
+}
+
+// ReadWithBoundary is like Read, but also passes the callback the boundary that
+// terminated each chunk, which is always a suffix of chunk. This is mostly
+// useful with ChunkBoundaries, to tell which of the candidates was matched. The
+// final chunk of a stream that doesn't end with a boundary gets an empty
+// boundary.
+func (r *ParallelReader) ReadWithBoundary(stream io.Reader, work func(chunk []byte, boundary []byte)) Result {
+	err := r.run(stream, nil, func(c *chunk) {
+		chunk := c.ReadableBytes()
+		work(chunk, r.trailingBoundary(chunk))
+	})
+	return r.finish(err)
+}
+
+// ReadLineNumbered is like Read, but also passes the callback the 1-based line
+// number of the first line in each chunk, for producing file:line diagnostics.
+// Lines are counted by the number of "\n" characters in the chunks preceding
+// each one, whatever ChunkBoundary is; counting happens on the scanning
+// goroutine, adding some serial work proportional to the input size. Bytes
+// that aren't delivered in any chunk (such as those dropped by RequireBoundary)
+// aren't counted.
+func (r *ParallelReader) ReadLineNumbered(stream io.Reader, work func(startLine int, chunk []byte)) Result {
+	r.countLines = true
+	defer func() { r.countLines = false }()
+
+	err := r.run(stream, nil, func(c *chunk) {
+		work(c.startLine, c.ReadableBytes())
+	})
+	return r.finish(err)
+}
+
+// ReadSerial is like Read, but with a single worker, so the callback is called
+// for one chunk at a time, in the order the chunks appear in stream. This
+// trades away parallelism for determinism, which makes it useful for
+// debugging, and as a baseline when benchmarking other configurations.
+func (r *ParallelReader) ReadSerial(stream io.Reader, work func(chunk []byte)) Result {
+	concurrency := r.Concurrency
+	r.Concurrency = 1
+	defer func() { r.Concurrency = concurrency }()
+
+	return r.Read(stream, work)
+}
+
+// ReadFull is like Read, but passes the callback the whole pooled buffer that
+// holds each chunk, of length ChunkSize, along with the number of bytes at the
+// start of it that make up the chunk. The remainder of buf is free for the
+// callback to use as scratch space, saving it a separate allocation. A chunk
+// longer than ChunkSize (see MaxChunkSize) fills its buffer, leaving no scratch
+// space. As with Read, buf must not be used after the callback returns.
+func (r *ParallelReader) ReadFull(stream io.Reader, work func(buf []byte, readableSize int)) Result {
+	err := r.run(stream, nil, func(c *chunk) {
+		work(c.buffer, c.readableSize)
+	})
+	return r.finish(err)
+}
+
+// run scans stream into chunks and calls fn for each of them from the pool of
+// worker goroutines, returning once all chunks have been processed.
+func (r *ParallelReader) run(stream io.Reader, done <-chan struct{}, fn func(c *chunk)) error {
+	return r.dispatch(r.producer(stream), stream, done, r.normalizingLineEndings(fn))
+}
+
+// producer returns the producer for dispatch that splits stream on
+// ChunkBoundary: scan, or with Reverse, reverseScan.
+func (r *ParallelReader) producer(stream io.Reader) func(io.Reader, <-chan struct{}) error {
+	if r.Reverse {
+		return r.reverseScan(stream)
+	}
+	return r.scan
+}
+
+// runFixed is run, but splits stream into fixed size chunks like ReadFixed.
+func (r *ParallelReader) runFixed(stream io.Reader, done <-chan struct{}, fn func(c *chunk)) error {
+	return r.dispatch(r.readFixed, stream, done, fn)
+}
+
+// dispatch calls produce in the foreground to send chunks of stream to the
+// pool of worker goroutines, which call fn for each of them.
+func (r *ParallelReader) dispatch(produce func(io.Reader, <-chan struct{}) error, stream io.Reader, done <-chan struct{}, fn func(c *chunk)) error {
+	return r.dispatchBatches(produce, stream, done, 1, func(batch []*chunk) {
+		fn(batch[0])
+	})
+}
+
+// dispatchBatches is dispatch, but the workers call fn with batches of up to
+// batchSize chunks.
+func (r *ParallelReader) dispatchBatches(produce func(io.Reader, <-chan struct{}) error, stream io.Reader, done <-chan struct{}, batchSize int, fn func(batch []*chunk)) error {
+	r.preparePool()
 	r.chunks = make(chan *chunk, r.Concurrency)
+	r.queue = r.chunks
+	if r.Priority != nil && !r.serial && !r.ordered {
+		r.queue = r.prioritize(r.chunks)
+	}
+	r.stats = new(Stats)
+	r.processed.reset()
+	r.completed = false
+	r.remainder = nil
+	r.checkpoints = r.startCheckpoints(r.checkpointBase)
+	defer func() { r.checkpoints = nil }()
 
-	scanner := bufio.NewScanner(stream)
+	done, recovered := r.watchPanics(done)
 
-	scanBuf := make([]byte, r.ChunkSize)
-	scanner.Buffer(scanBuf, r.ChunkSize)
+	// Start the worker goroutines that receive chunks of data in parallel, or
+	// for a small input, have send process each chunk on this goroutine.
+	var wg *sync.WaitGroup
+	var abandoned atomic.Bool
+	if r.serial {
+		r.inline = r.processInline(fn)
+		defer func() { r.inline = nil }()
+	} else {
+		wg = r.startWorkers(batchSize, &abandoned, fn)
+	}
+
+	err := produce(r.skippingToFirstBoundary(r.rejectingBinary(r.stripBOM(r.hashing(r.limiting(r.retrying(stream)))))), done)
+
+	close(r.chunks)
+	if wg != nil && !r.waitWorkers(wg, done) {
+		abandoned.Store(true)
+		err = ErrShutdownIncomplete
+	}
+
+	// A panic is reported in preference to any error from the stream, which may
+	// well have been caused by the run being stopped.
+	if panicErr := recovered(); panicErr != nil {
+		err = panicErr
+	}
+	if r.checkpoints != nil {
+		if checkpointErr := r.checkpoints.finish(); err == nil {
+			err = checkpointErr
+		}
+	}
+
+	if r.WholeHash != nil {
+		r.stats.WholeHash = r.WholeHash.Sum(nil)
+	}
+
+	if r.truncated() {
+		r.completed = false
+		if r.remainder == nil {
+			r.remainder = r.limit.rest()
+		}
+		if err == nil && r.FailOnMaxBytes {
+			err = ErrMaxBytes
+		}
+	}
+
+	return r.checkRecordCount(err)
+}
+
+// Warm fills the buffer pool with PoolSize buffers of ChunkSize ahead of time,
+// so that the first chunks of the next read don't need to allocate. This is
+// useful when latency of the very first read matters, at the cost of holding
+// the pool's memory up front.
+func (r *ParallelReader) Warm() {
+	r.preparePool()
+	r.pool.Fill()
+}
+
+// Pool returns the buffer pool the next read will use, creating it if needed,
+// e.g. to set its OnBorrow and OnReturn hooks. The pool is replaced, hooks and
+// all, if ChunkSize, PoolSize, Concurrency, BufferAlignment or PoisonBuffers
+// change before the read.
+func (r *ParallelReader) Pool() *Pool {
+	r.preparePool()
+	return r.pool
+}
+
+// poolSize returns PoolSize, or its default if it's zero.
+func (r *ParallelReader) poolSize() int {
+	if r.PoolSize > 0 {
+		return r.PoolSize
+	}
+	size := r.Concurrency*max(r.BatchSize, 1) + r.Prefetch
+	if r.NoCopy {
+		size *= 2
+	}
+	return size
+}
 
-	// Start the worker goroutines that receive chunks of data in parallel.
-	wg := r.startWorkers(work)
+// preparePool creates a new buffer pool, unless the existing one still matches
+// the reader's configuration.
+func (r *ParallelReader) preparePool() {
+	size := r.poolSize()
 
-	// Scan the input stream in the foreground, splitting data into chunks as
-	// close to ChunkSize as possible while respecting ChunkBoundary.
-	scanner.Split(r.ScanChunksWithBoundary)
+	// The pool may be shared with copies of the reader, and workers abandoned
+	// by ShutdownGrace, so it's replaced rather than changed in place.
+	if r.pool == nil || r.pool.bufferSize != r.ChunkSize || cap(r.pool.pool) != size || r.pool.alignment != r.BufferAlignment || r.pool.poison != r.PoisonBuffers {
+		r.pool = NewPool(size, r.ChunkSize)
+		r.pool.alignment = r.BufferAlignment
+		r.pool.poison = r.PoisonBuffers
+		r.pool.counts = r.poolCounts
+	}
+}
+
+// scan reads stream in the foreground, splitting data into chunks as close to
+// ChunkSize as possible while respecting ChunkBoundary, and sends them to
+// r.chunks in order. Each chunk is tagged with its index and byte offset in the
+// stream. Closing done stops the scan early.
+func (r *ParallelReader) scan(stream io.Reader, done <-chan struct{}) error {
+	var scanner tokenScanner
+	var pos *scanPosition
+	var ring *ringScanner
+	if r.NoCopy && !r.discard && r.Lookahead <= 0 {
+		ring, pos = r.newRingScanner(stream)
+		defer ring.close()
+		scanner = ring
+	} else {
+		scanner, pos = r.newScanner(stream)
+	}
+
+	r.autoChunkSize = 0
+	r.calibration.records, r.calibration.bytes = 0, 0
+	if r.AutoChunkSize {
+		r.autoChunkSize = autoInitialChunkSize
+	}
+
+	// With NoCopy, take moves the current token, and what was buffered after
+	// it, and the buffer of a chunk that can't be sent goes back to the pool,
+	// to be reused or poisoned, so the remainder is recorded from current, where
+	// the token was moved to, before that happens.
+	var current []byte
+
+	// Chunks are indexed as they're sent, rather than as they're scanned, since
+	// CoalesceFinal may merge two of them into one.
+	index := 0
+	emit := func(c *chunk) bool {
+		c.index = index
+		if r.scanned != nil {
+			r.scanned(c)
+		} else if r.discard {
+			r.countChunk(c)
+		} else if !r.deliver(c, done) {
+			if ring != nil && r.remainder == nil {
+				r.stopped(current, ring.buffered(), stream)
+			}
+			r.pool.Return(c.buffer)
+			return false
+		}
+		index++
+		return true
+	}
+
+	var marker *finalMarker
+	if r.markFinal {
+		marker = &finalMarker{pool: r.pool, emit: emit}
+		emit = marker.add
+	}
+
+	var coalescer *finalCoalescer
+	if r.CoalesceFinal {
+		// Discarded chunks are slices of the scanner's buffer, which mustn't end
+		// up in the pool, so they're returned to an empty one that keeps nothing.
+		pool := r.pool
+		if r.discard {
+			pool = &Pool{}
+		}
+		coalescer = &finalCoalescer{pool: pool, minSize: r.MinChunkSize, emit: emit}
+		emit = coalescer.add
+	}
+
+	sample := r.newSampler(0)
+	line := 1
 	for scanner.Scan() {
 		// Scanner reuses its internal buffer while scanning, so in order to safely
 		// pass the bytes to a channel where they will be read concurrently, we have
 		// to copy them. Rather than allocating a new block of memory each time, we
 		// reuse an existing pool of buffers.
 		token := scanner.Bytes()
-		buf := r.pool.Borrow()
+		if len(token) == 0 && !r.EmitEmptyFinalChunk {
+			continue
+		}
+		if r.AutoChunkSize {
+			r.calibrateChunkSize(token)
+		}
+		if r.ExpectedRecords > 0 {
+			atomic.AddInt64(&r.stats.Records, r.recordsIn(token))
+		}
+		if (r.Filter != nil && !r.Filter(token)) || (sample != nil && !sample.keep()) {
+			if r.countLines {
+				line += bytes.Count(token, []byte("\n"))
+			}
+			continue
+		}
+
+		// A record longer than ChunkSize makes for a chunk that won't fit in a
+		// pooled buffer, so it gets one of its own, which Return discards. Nothing
+		// reads a discarded chunk, so it needn't be copied at all, and with NoCopy
+		// it's already in a buffer of its own. Any lookahead follows the token in
+		// the scanner's buffer, so it's delivered by extending the token over it.
+		delivered := token[:len(token)+len(pos.lookahead)]
+		var buf []byte
+		switch {
+		case r.discard:
+			buf = delivered
+		case ring != nil:
+			buf = ring.take()
+			current = buf[:len(token)]
+		case len(delivered) > r.ChunkSize:
+			buf = r.pool.alloc(len(delivered))
+			copy(buf, delivered)
+		default:
+			buf = r.pool.Borrow()
+			copy(buf, delivered)
+		}
+		c := &chunk{buffer: buf, readableSize: len(delivered), lookahead: len(pos.lookahead), offset: pos.tokenOffset, startLine: line}
+		if r.countLines {
+			line += bytes.Count(token, []byte("\n"))
+		}
 
-		if len(token) > 0 {
-			size := copy(buf, token)
-			r.chunks <- &chunk{buffer: buf, readableSize: size}
+		if !emit(c) {
+			if ring == nil {
+				r.stopped(token, pos.unread, stream)
+			}
+			return nil
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		panic(err)
+	if coalescer != nil && !coalescer.flush() {
+		return nil
 	}
 
-	close(r.chunks)
-	wg.Wait()
+	err := scanner.Err()
+	if marker != nil && !marker.flush(err == nil) {
+		return nil
+	}
+	r.completed = err == nil
+	return err
+}
+
+// scanPosition tracks a scanner's progress through its stream.
+type scanPosition struct {
+	// The offset in the stream at which the most recently scanned token starts.
+	tokenOffset int64
+	// The data the scanner has buffered after the most recently scanned token,
+	// which is only valid until the next call to Scan.
+	unread []byte
+	// The start of unread that's delivered with the token for Lookahead.
+	lookahead []byte
+}
+
+// newScanner returns a bufio.Scanner that splits stream with
+// ScanChunksWithBoundary, along with its position in stream.
+func (r *ParallelReader) newScanner(stream io.Reader) (*bufio.Scanner, *scanPosition) {
+	scanner := bufio.NewScanner(stream)
+
+	scanBuf := make([]byte, r.ChunkSize)
+	scanner.Buffer(scanBuf, r.maxChunkSize()+max(r.Lookahead, 0))
+
+	split, pos := r.newSplit()
+	scanner.Split(split)
+	return scanner, pos
+}
+
+// newSplit returns the bufio.SplitFunc for newScanner, which splits with
+// ScanChunksWithBoundary (or scanFirstRecord, for the first record if
+// FirstBoundary is set), along with the position it tracks in the stream.
+func (r *ParallelReader) newSplit() (bufio.SplitFunc, *scanPosition) {
+	// Track the offset in the stream at which each token starts, so that it can
+	// be reported along with the chunk. Tokens are always slices of the data
+	// passed to the split function, so the difference in capacity gives the
+	// token's position within it.
+	var consumed int64
+	pos := &scanPosition{}
+	firstPending := r.FirstBoundary != ""
+
+	// Once the target size has been buffered, ScanChunksWithBoundary searches
+	// everything buffered for a boundary each time more is read, which is
+	// quadratic in the length of a long record when reads are small, as they
+	// can be from pipes and network streams. searched is how much of data is
+	// known not to contain one, so that only what's been read since needs to be
+	// searched before asking for more.
+	searched := 0
+	splitFunc := func(data []byte, atEOF bool) (int, []byte, error) {
+		split := r.ScanChunksWithBoundary
+		if firstPending {
+			split = r.scanFirstRecord
+		} else if !atEOF && r.BoundaryFunc == nil && r.hasBoundary() && len(data) >= r.targetChunkSize() {
+			from := max(searched-r.longestBoundary()+1, 0)
+			if r.sharedMarker() {
+				// The marker a chunk starts with can't also end it.
+				from = max(from, len(r.ChunkBoundary))
+			}
+			if !r.containsBoundary(data[from:]) {
+				searched = len(data)
+				// None of data has a boundary, so it's all one record.
+				if r.MaxLineLength > 0 && len(data) > r.MaxLineLength {
+					return 0, nil, ErrMaxLineLength
+				}
+				return 0, nil, nil
+			}
+		}
+
+		advance, token, err := split(data, atEOF)
+		if r.MaxLineLength > 0 && r.BoundaryFunc == nil && r.hasBoundary() && (err == nil || err == bufio.ErrFinalToken) {
+			// A token's records are checked before it's delivered, and while more
+			// data is needed, so is the record still being read.
+			pending := token
+			if token == nil && err == nil {
+				pending = data[max(r.lastBoundaryEnd(data, atEOF), 0):]
+			}
+			if r.recordTooLong(pending, atEOF) {
+				return 0, nil, ErrMaxLineLength
+			}
+		}
+		if token != nil && err == nil && r.Lookahead > 0 {
+			// Hold on to the token until enough follows it to fill the lookahead,
+			// which the scanner buffers without advancing over it.
+			end := cap(data) - cap(token) + len(token)
+			if !atEOF && len(data)-end < r.Lookahead {
+				return 0, nil, nil
+			}
+			pos.lookahead = data[end:min(end+r.Lookahead, len(data))]
+		} else if token != nil {
+			pos.lookahead = nil
+		}
+		if token != nil {
+			firstPending = false
+			pos.tokenOffset = consumed + int64(cap(data)-cap(token))
+		}
+		if advance > 0 {
+			searched = 0
+		}
+		consumed += int64(advance)
+
+		// A final token takes the rest of the data, without advancing over it.
+		pos.unread = data[advance:]
+		if err == bufio.ErrFinalToken {
+			pos.unread = nil
+		}
+		return advance, token, err
+	}
+
+	if r.DebugSplit != nil {
+		inner := splitFunc
+		splitFunc = func(data []byte, atEOF bool) (int, []byte, error) {
+			advance, token, err := inner(data, atEOF)
+			tokenLen := -1
+			if token != nil {
+				tokenLen = len(token)
+			}
+			r.DebugSplit(len(data), atEOF, advance, tokenLen)
+			return advance, token, err
+		}
+	}
+
+	return splitFunc, pos
 }
 
 // ReadFixed is a specialized, faster implementation when the input stream can
 // be split into fixed size chunks without needing to respect a record boundary.
 // The final chunk will be less than ChunkSize if the stream or file's length is
 // not evenly divisible by ChunkSize.
-func (r *ParallelReader) ReadFixed(stream io.Reader, work func(chunk []byte)) {
-	r.pool = NewPool(r.Concurrency, r.ChunkSize)
-	r.chunks = make(chan *chunk, r.Concurrency)
+//
+// Every other chunk is exactly ChunkSize bytes, however short the stream's
+// reads are, so setting ChunkSize to a downstream block size, like the part
+// size of a multipart upload, aligns chunks to it. Only the end of the stream,
+// or an error or MaxBytes cutting the read short, makes for a shorter chunk,
+// and it's always the final one.
+func (r *ParallelReader) ReadFixed(stream io.Reader, work func(chunk []byte)) Result {
+	err := r.runFixed(stream, nil, func(c *chunk) {
+		work(c.ReadableBytes())
+	})
+	return r.finish(err)
+}
+
+// readFixed reads stream in the foreground, sending chunks of exactly ChunkSize
+// to r.chunks in order, except for the final chunk which may be smaller.
+// Closing done stops the read early.
+func (r *ParallelReader) readFixed(stream io.Reader, done <-chan struct{}) error {
+	if r.Prefetch > 0 {
+		return r.readFixedPrefetched(stream, done)
+	}
+
+	err := r.readFixedChunks(stream, func(c *chunk) bool {
+		return r.send(c, done)
+	})
+	if err == io.EOF {
+		// We've reached the end of the stream. We're done!
+		r.completed = true
+		return nil
+	}
+	return err
+}
 
-	wg := r.startWorkers(work)
+// readFixedChunks reads stream into chunks of exactly ChunkSize, except for the
+// final chunk which may be smaller, and calls emit with each of them in order.
+// It returns io.EOF once the whole stream has been emitted, or nil if emit
+// returns false to stop early.
+func (r *ParallelReader) readFixedChunks(stream io.Reader, emit func(c *chunk) bool) error {
+	var offset int64
 
-	for {
+	for index := 0; ; index++ {
 		buf := r.pool.Borrow()
 
 		// io.ReadFull() will read up to cap(buf) if it doesn't reach EOF first. If it
 		// does encounter an EOF before buf is full, the actual read size is
 		// returned and err will be io.ErrUnexpectedEOF.
 		actualReadSize, err := io.ReadFull(stream, buf)
-		chunk := chunk{buffer: buf, readableSize: actualReadSize}
 
-		if err == nil {
-			r.chunks <- &chunk
-			continue
-		}
-		if err != nil {
-			// We're at EOF, but there's still some data, so send it to the channel
-			// before finishing.
-			if err == io.ErrUnexpectedEOF {
-				r.chunks <- &chunk
-				close(r.chunks)
-				break
-				// We arrived at EOF with nothing left to read. We're done!
-			} else if err == io.EOF {
-				close(r.chunks)
-				break
-			} else {
-				panic(err)
+		// If there's any data, even at EOF, send it to the channel before
+		// finishing.
+		if actualReadSize > 0 {
+			if r.ExpectedRecords > 0 {
+				atomic.AddInt64(&r.stats.Records, 1)
 			}
+			if !emit(&chunk{buffer: buf, readableSize: actualReadSize, index: index, offset: offset}) {
+				return nil
+			}
+			offset += int64(actualReadSize)
+		} else {
+			r.pool.Return(buf)
+		}
+
+		switch err {
+		case nil:
+			continue
+		case io.EOF, io.ErrUnexpectedEOF:
+			return io.EOF
+		default:
+			return err
 		}
 	}
+}
+
+// send sends c to the workers, returning false (and the chunk's buffer to the
+// pool) if done is closed first.
+func (r *ParallelReader) send(c *chunk, done <-chan struct{}) bool {
+	if !r.deliver(c, done) {
+		r.pool.Return(c.buffer)
+		return false
+	}
+	return true
+}
+
+// deliver is send, but leaves the chunk's buffer to the caller if done is
+// closed first.
+func (r *ParallelReader) deliver(c *chunk, done <-chan struct{}) bool {
+	var start time.Time
+	if r.Profile {
+		start = time.Now()
+	}
+
+	// Check done first, since select picks at random when both cases are ready.
+	select {
+	case <-done:
+		return false
+	default:
+	}
+
+	if r.inline != nil {
+		r.countChunk(c)
+		r.inline(c)
+		return true
+	}
+
+	select {
+	case r.chunks <- c:
+	case <-done:
+		return false
+	}
 
-	wg.Wait()
+	if r.Profile {
+		r.stats.ScannerBlockedNanos += int64(time.Since(start))
+	}
+	r.countChunk(c)
+	return true
 }
 
-func (r *ParallelReader) startWorkers(fn func(chunk []byte)) *sync.WaitGroup {
+// startWorkers starts Concurrency workers that receive chunks and call fn with
+// batches of up to batchSize of them, returning their buffers to the pool once
+// fn returns. Once abandoned is set, they stop processing chunks, and leave
+// their buffers alone.
+func (r *ParallelReader) startWorkers(batchSize int, abandoned *atomic.Bool, fn func(batch []*chunk)) *sync.WaitGroup {
+	// Workers hold on to the run's queue, pool, checkpoints, panics and stats,
+	// since a worker that's been abandoned by waitWorkers may still be running
+	// when the next read starts.
+	queue, pool, checkpoints := r.queue, r.pool, r.checkpoints
+	panics, stats := r.panics, r.stats
+
 	var wg sync.WaitGroup
 	wg.Add(r.Concurrency)
 	for i := 0; i < r.Concurrency; i++ {
 		go func() {
 			defer wg.Done()
-			for chunk := range r.chunks {
-				fn(chunk.ReadableBytes())
-				r.pool.Return(chunk.buffer)
+			if r.LockWorkerThreads {
+				runtime.LockOSThread()
+				defer runtime.UnlockOSThread()
+			}
+
+			work := r.withMiddleware(batchSize, fn)
+			batch := make([]*chunk, 0, batchSize)
+			flush := func() {
+				ok := r.call(panics, func() { work(batch) })
+				// Once the run has been abandoned, its buffers may still be in use by
+				// the callbacks left behind alongside this one, and its counts are
+				// over.
+				if !abandoned.Load() {
+					for _, c := range batch {
+						r.processed.add(c)
+						if checkpoints != nil {
+							checkpoints.done(c, ok && !c.failed)
+						}
+						pool.Return(c.buffer)
+					}
+				}
+				batch = batch[:0]
+			}
+
+			for {
+				chunk, ok := r.receive(queue, stats)
+				if !ok {
+					break
+				}
+				// Keep draining an abandoned run's queue, so that nothing sending
+				// to it blocks, but without processing what's left.
+				if abandoned.Load() {
+					continue
+				}
+				batch = append(batch, chunk)
+				if len(batch) == batchSize {
+					flush()
+				}
+			}
+			if len(batch) > 0 && !abandoned.Load() {
+				flush()
 			}
 		}()
 	}
 	return &wg
 }
 
+// waitWorkers waits for the workers in wg to finish. Once done is closed, it
+// only waits up to ShutdownGrace, if it's set and the run is graceful, and
+// reports whether they finished in time.
+func (r *ParallelReader) waitWorkers(wg *sync.WaitGroup, done <-chan struct{}) bool {
+	if !r.graceful || r.ShutdownGrace <= 0 {
+		wg.Wait()
+		return true
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return true
+	case <-done:
+	}
+
+	timer := time.NewTimer(r.ShutdownGrace)
+	defer timer.Stop()
+	select {
+	case <-finished:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// processInline returns a function that processes a chunk the way a worker
+// would, for send to call in place of sending it to one.
+func (r *ParallelReader) processInline(fn func(batch []*chunk)) func(c *chunk) {
+	work := r.withMiddleware(1, fn)
+	checkpoints, panics := r.checkpoints, r.panics
+	return func(c *chunk) {
+		ok := r.call(panics, func() { work([]*chunk{c}) })
+		r.processed.add(c)
+		if checkpoints != nil {
+			checkpoints.done(c, ok && !c.failed)
+		}
+		r.pool.Return(c.buffer)
+	}
+}
+
+// measure reports whether stream is known to be smaller than MinParallelSize,
+// returning a stream to read in its place that replays anything read from it
+// to find out.
+func (r *ParallelReader) measure(stream io.Reader) (io.Reader, bool) {
+	if l, ok := stream.(interface{ Len() int }); ok {
+		return stream, l.Len() < r.MinParallelSize
+	}
+
+	// A seekable stream is measured without reading it, which also leaves it
+	// seekable for ParallelScan.
+	if source, ok := seekable(stream); ok {
+		if size, err := remainingSize(source); err == nil {
+			return stream, size < int64(r.MinParallelSize)
+		}
+	}
+
+	br := bufio.NewReaderSize(stream, r.MinParallelSize)
+	data, err := br.Peek(r.MinParallelSize)
+	return br, err == io.EOF && len(data) < r.MinParallelSize
+}
+
+// remainingSize returns the number of bytes from source's current position to
+// its end, leaving it where it was.
+func remainingSize(source io.Seeker) (int64, error) {
+	start, err := source.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := source.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	_, err = source.Seek(start, io.SeekStart)
+	return end - start, err
+}
+
+// receive waits for the next chunk to be sent to the workers, adding the time
+// it waited to stats if Profile is set.
+func (r *ParallelReader) receive(queue <-chan *chunk, stats *Stats) (*chunk, bool) {
+	if !r.Profile {
+		c, ok := <-queue
+		return c, ok
+	}
+
+	start := time.Now()
+	c, ok := <-queue
+	atomic.AddInt64(&stats.WorkerIdleNanos, int64(time.Since(start)))
+	return c, ok
+}
+
 // Custom bufio.Scanner split function that returns chunks of bytes as close to
 // the configured ChunkSize as possible, while respecting the record boundary
 // specified by ChunkBoundary. See bufio.Scanner documentation for more details
 // about this method.
+//
+// If neither ChunkBoundary nor ChunkBoundaries is set, there are no records to
+// respect, so data is split into chunks of exactly ChunkSize like ReadFixed,
+// with a shorter final chunk. Empty candidates in ChunkBoundaries are ignored.
+//
+// If ChunkBoundaryStart is the same as ChunkBoundary, records are delimited by
+// a repeated marker, as with pages separated by form feeds, so each marker both
+// ends one record and starts the next. Each chunk then begins with a marker and
+// ends just before the marker that begins the next chunk: "#rec1#rec2#rec3#"
+// is split into records "#rec1", "#rec2" and "#rec3", and the closing marker
+// at the end of the stream, which begins no record, isn't delivered. A final
+// record without a closing marker is dropped if RequireBoundary is set. This
+// disables ParallelScan.
+//
+// If BoundaryFunc is set, it's used to find the end of each chunk instead.
 func (r *ParallelReader) ScanChunksWithBoundary(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if r.BoundaryFunc != nil {
+		return r.scanBoundaryFunc(data, atEOF)
+	}
+	if !r.hasBoundary() {
+		return r.scanFixedSize(data, atEOF)
+	}
+	if r.sharedMarker() {
+		return r.scanSharedMarker(data, atEOF)
+	}
+
 	// Request more data until we've read up to at least our desired chunk size.
-	if !atEOF && len(data) < r.ChunkSize {
+	target := r.targetChunkSize()
+	if !atEOF && len(data) < target {
 		return 0, nil, nil
 	}
 
 	// Now that we have the desired chunk size, return the slice of the buffer
 	// that ends with ChunkBoundary, instructing the Scanner to advance to the end
-	// of the boundary on the next read.
-	startIdx := bytes.Index(data, []byte(r.ChunkBoundaryStart))
-	endIdx := bytes.LastIndex(data, []byte(r.ChunkBoundary))
-	if endIdx > -1 {
-		boundaryEnd := endIdx + len(r.ChunkBoundary)
+	// of the boundary on the next read. The scanner may have read further than
+	// the target, so look for a boundary within the target first.
+	boundaryEnd := -1
+	if len(data) > target {
+		boundaryEnd = r.lastBoundaryEnd(data[:target], false)
+		// If there isn't one, a record longer than the target was buffered; end
+		// the chunk straight after it rather than taking everything buffered.
+		if boundaryEnd < 0 {
+			boundaryEnd = r.firstBoundaryEnd(data, atEOF)
+		}
+	}
+	if boundaryEnd < 0 {
+		boundaryEnd = r.lastBoundaryEnd(data, atEOF)
+	}
+	if boundaryEnd > -1 {
+		startIdx := r.index(data[:boundaryEnd], []byte(r.ChunkBoundaryStart))
+		if startIdx < 0 {
+			// Nothing up to the boundary is after a start marker, so it's all
+			// dropped, like anything else before one.
+			return boundaryEnd, nil, nil
+		}
 		return boundaryEnd, data[startIdx:boundaryEnd], nil
 	}
 
@@ -152,32 +1346,410 @@ func (r *ParallelReader) ScanChunksWithBoundary(data []byte, atEOF bool) (advanc
 		return 0, nil, nil
 	}
 
+	return r.finalToken(data)
+}
+
+// finalToken returns data as the final token of the stream, unless it has to
+// be dropped for not ending with a boundary.
+func (r *ParallelReader) finalToken(data []byte) (advance int, token []byte, err error) {
 	// There is one final token to be delivered, which may be an empty string.
 	// Returning bufio.ErrFinalToken here tells Scan there are no more tokens
 	// after this but does not trigger an error to be returned from Scan itself.
-	if r.RequireBoundary {
+	if r.RequireBoundary || r.truncated() {
+		if r.truncated() {
+			r.limit.cut = append([]byte(nil), data...)
+		}
+		if len(data) > 0 {
+			atomic.AddInt64(&r.stats.DroppedBytes, int64(len(data)))
+			if r.OnDropped != nil {
+				r.OnDropped(data)
+			}
+		}
 		return 0, nil, bufio.ErrFinalToken
 	} else {
 		return 0, data, bufio.ErrFinalToken
 	}
 }
 
+// scanBoundaryFunc is ScanChunksWithBoundary for BoundaryFunc. It packs as
+// many whole records into the chunk as fit in the target, or takes just the
+// first record if it's longer than that.
+func (r *ParallelReader) scanBoundaryFunc(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	target := r.targetChunkSize()
+	if !atEOF && len(data) < target {
+		return 0, nil, nil
+	}
+
+	end := 0
+	for end < len(data) {
+		n := r.BoundaryFunc(data[end:], atEOF)
+		if n <= 0 {
+			break
+		}
+		if n > len(data)-end {
+			return 0, nil, bufio.ErrAdvanceTooFar
+		}
+		if end > 0 && end+n > target {
+			break
+		}
+		end += n
+	}
+	if end > 0 {
+		return end, data[:end], nil
+	}
+
+	if !atEOF {
+		return 0, nil, nil
+	}
+	return r.finalToken(data)
+}
+
+// sharedMarker reports whether ChunkBoundaryStart and ChunkBoundary are the
+// same marker, which is then split on by scanSharedMarker.
+func (r *ParallelReader) sharedMarker() bool {
+	return len(r.ChunkBoundaries) == 0 && r.ChunkBoundaryStart != "" &&
+		r.equal([]byte(r.ChunkBoundaryStart), []byte(r.ChunkBoundary))
+}
+
+// scanSharedMarker is ScanChunksWithBoundary for a ChunkBoundaryStart that's
+// the same as ChunkBoundary. Anything before the first marker is skipped, as it
+// would be before a ChunkBoundaryStart otherwise.
+func (r *ParallelReader) scanSharedMarker(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	marker := []byte(r.ChunkBoundary)
+
+	target := r.targetChunkSize()
+	if !atEOF && len(data) < target {
+		return 0, nil, nil
+	}
+
+	start := r.index(data, marker)
+	if start < 0 {
+		if !atEOF {
+			return 0, nil, nil
+		}
+		return r.finalToken(data)
+	}
+
+	// The chunk ends before the last marker that starts within the target, or
+	// if a record longer than the target was buffered, before the first marker
+	// after it.
+	from := start + len(marker)
+	end := -1
+	if len(data) > target {
+		if from < target {
+			end = r.lastIndex(data[from:target], marker)
+		}
+		if end < 0 {
+			end = r.index(data[from:], marker)
+		}
+	} else {
+		end = r.lastIndex(data[from:], marker)
+	}
+	if end > -1 {
+		end += from
+		return end, data[start:end], nil
+	}
+
+	if !atEOF {
+		return 0, nil, nil
+	}
+
+	// A marker on its own at the end of the stream just closes the last record.
+	if from == len(data) {
+		return 0, nil, bufio.ErrFinalToken
+	}
+	return r.finalToken(data[start:])
+}
+
+// maxChunkSize returns MaxChunkSize, or its default of
+// defaultMaxChunkSizeFactor times ChunkSize if it isn't set. It's never less
+// than ChunkSize.
+func (r *ParallelReader) maxChunkSize() int {
+	if r.MaxChunkSize <= 0 {
+		return r.ChunkSize * defaultMaxChunkSizeFactor
+	}
+	return max(r.MaxChunkSize, r.ChunkSize)
+}
+
+// hasBoundary reports whether ChunkBoundary or any of ChunkBoundaries is set.
+func (r *ParallelReader) hasBoundary() bool {
+	if len(r.ChunkBoundaries) == 0 {
+		return r.ChunkBoundary != ""
+	}
+	for _, boundary := range r.ChunkBoundaries {
+		if boundary != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// scanFixedSize is a bufio.SplitFunc that splits data into chunks of
+// ChunkSize, for when there's no boundary to split on.
+func (r *ParallelReader) scanFixedSize(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) >= r.ChunkSize {
+		return r.ChunkSize, data[:r.ChunkSize], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// scanFirstRecord is a bufio.SplitFunc that splits off the first record of a
+// stream, up to and including FirstBoundary. If the stream ends without one,
+// it's split like any other.
+func (r *ParallelReader) scanFirstRecord(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if idx := r.index(data, []byte(r.FirstBoundary)); idx > -1 {
+		n := idx + len(r.FirstBoundary)
+		return n, data[:n], nil
+	}
+	if !atEOF {
+		return 0, nil, nil
+	}
+	return r.ScanChunksWithBoundary(data, atEOF)
+}
+
+// targetChunkSize returns the size ScanChunksWithBoundary aims for, which is
+// ChunkSize less any Lookahead, unless AutoChunkSize has picked a smaller one.
+func (r *ParallelReader) targetChunkSize() int {
+	size := r.ChunkSize
+	if r.Lookahead > 0 {
+		size = max(size-r.Lookahead, 1)
+	}
+	if r.autoChunkSize > 0 && r.autoChunkSize < size {
+		return r.autoChunkSize
+	}
+	return size
+}
+
+// calibrateChunkSize updates the target chunk size used by AutoChunkSize from
+// the average size of the records seen so far, until autoCalibrationRecords
+// have been seen.
+func (r *ParallelReader) calibrateChunkSize(token []byte) {
+	if r.calibration.records >= autoCalibrationRecords {
+		return
+	}
+
+	r.calibration.records += r.countRecords(token)
+	r.calibration.bytes += len(token)
+	if r.calibration.records == 0 {
+		return
+	}
+
+	perChunk := r.RecordsPerChunk
+	if perChunk <= 0 {
+		perChunk = defaultRecordsPerChunk
+	}
+
+	r.autoChunkSize = r.calibration.bytes / r.calibration.records * perChunk
+	if r.autoChunkSize < 1 {
+		r.autoChunkSize = 1
+	}
+}
+
+// containsBoundary reports whether data contains ChunkBoundary, or any of
+// ChunkBoundaries if set.
+func (r *ParallelReader) containsBoundary(data []byte) bool {
+	if len(r.ChunkBoundaries) == 0 {
+		return r.contains(data, []byte(r.ChunkBoundary))
+	}
+	for _, boundary := range r.ChunkBoundaries {
+		if boundary != "" && r.contains(data, []byte(boundary)) {
+			return true
+		}
+	}
+	return false
+}
+
+// longestBoundary returns the length of ChunkBoundary, or of the longest of
+// ChunkBoundaries if set.
+func (r *ParallelReader) longestBoundary() int {
+	if len(r.ChunkBoundaries) == 0 {
+		return len(r.ChunkBoundary)
+	}
+	longest := 0
+	for _, boundary := range r.ChunkBoundaries {
+		longest = max(longest, len(boundary))
+	}
+	return longest
+}
+
+// countRecords returns the number of boundaries in token. With
+// ChunkBoundaries, overlapping candidates (like "\n" and "\r\n") may be counted
+// twice, which is close enough for calibrating AutoChunkSize.
+func (r *ParallelReader) countRecords(token []byte) int {
+	if len(r.ChunkBoundaries) == 0 {
+		if r.ChunkBoundary == "" {
+			return 0
+		}
+		return r.count(token, []byte(r.ChunkBoundary))
+	}
+
+	count := 0
+	for _, boundary := range r.ChunkBoundaries {
+		if boundary != "" {
+			count += r.count(token, []byte(boundary))
+		}
+	}
+	return count
+}
+
+// lastBoundaryEnd returns the index just past the last ChunkBoundary in data, or
+// -1 if there isn't one. If ChunkBoundaries is set, the rightmost match of any
+// of them is used instead.
+func (r *ParallelReader) lastBoundaryEnd(data []byte, atEOF bool) int {
+	if len(r.ChunkBoundaries) == 0 {
+		if idx := r.lastIndex(data, []byte(r.ChunkBoundary)); idx > -1 {
+			return idx + len(r.ChunkBoundary)
+		}
+		return -1
+	}
+
+	end := -1
+	for _, boundary := range r.ChunkBoundaries {
+		if boundary == "" {
+			continue
+		}
+		for search := data; ; {
+			idx := r.lastIndex(search, []byte(boundary))
+			if idx < 0 {
+				break
+			}
+			// A match that might turn out to be the start of a longer candidate
+			// once more data is read isn't safe to split on yet; look for an
+			// earlier one instead.
+			if !atEOF && r.couldBeLongerBoundary(data[idx:], boundary) {
+				search = search[:idx]
+				continue
+			}
+			if idx+len(boundary) > end {
+				end = idx + len(boundary)
+			}
+			break
+		}
+	}
+	return end
+}
+
+// firstBoundaryEnd returns the index just past the first ChunkBoundary in
+// data, or -1 if there isn't one. If ChunkBoundaries is set, the leftmost match
+// of any of them is used instead, preferring the longest candidate when several
+// match at the same position.
+func (r *ParallelReader) firstBoundaryEnd(data []byte, atEOF bool) int {
+	if len(r.ChunkBoundaries) == 0 {
+		if idx := r.index(data, []byte(r.ChunkBoundary)); idx > -1 {
+			return idx + len(r.ChunkBoundary)
+		}
+		return -1
+	}
+
+	start, end := -1, -1
+	for _, boundary := range r.ChunkBoundaries {
+		if boundary == "" {
+			continue
+		}
+		for offset := 0; ; {
+			idx := r.index(data[offset:], []byte(boundary))
+			if idx < 0 {
+				break
+			}
+			idx += offset
+			if !atEOF && r.couldBeLongerBoundary(data[idx:], boundary) {
+				offset = idx + 1
+				continue
+			}
+			if start < 0 || idx < start || (idx == start && idx+len(boundary) > end) {
+				start, end = idx, idx+len(boundary)
+			}
+			break
+		}
+	}
+	return end
+}
+
+// trailingBoundary returns the suffix of chunk that matches ChunkBoundary, or
+// the longest matching candidate in ChunkBoundaries if set. It returns an empty
+// slice if chunk doesn't end with a boundary.
+func (r *ParallelReader) trailingBoundary(chunk []byte) []byte {
+	boundaries := r.ChunkBoundaries
+	if len(boundaries) == 0 {
+		boundaries = []string{r.ChunkBoundary}
+	}
+
+	longest := 0
+	for _, boundary := range boundaries {
+		if len(boundary) > longest && r.hasSuffix(chunk, []byte(boundary)) {
+			longest = len(boundary)
+		}
+	}
+	return chunk[len(chunk)-longest:]
+}
+
+// couldBeLongerBoundary reports whether tail, which starts with boundary, is
+// also the beginning of a longer candidate in ChunkBoundaries that runs past the
+// end of the data read so far.
+func (r *ParallelReader) couldBeLongerBoundary(tail []byte, boundary string) bool {
+	for _, candidate := range r.ChunkBoundaries {
+		if len(candidate) > len(boundary) && len(candidate) > len(tail) && r.hasPrefix([]byte(candidate), tail) {
+			return true
+		}
+	}
+	return false
+}
+
 // Stores the backing buffer and length at which a receiver will need to slice
-// the backing buffer to get a full "token".
+// the backing buffer to get a full "token", along with the chunk's position in
+// the stream.
 type chunk struct {
 	readableSize int
-	buffer       []byte
+	// How many bytes at the end of the chunk are only there as Lookahead.
+	lookahead int
+	buffer    []byte
+	index     int
+	offset    int64
+	startLine int
+	// Whether the chunk is a region, for ReadRegions.
+	inside bool
+	// Whether the chunk is the last of the stream, for ReadMeta.
+	final bool
+	// Whether the chunk's callback failed or was skipped, so that Checkpoint
+	// doesn't count it as processed. Only the worker processing the chunk sets
+	// it.
+	failed bool
 }
 
 func (chunk *chunk) ReadableBytes() []byte {
 	return chunk.buffer[:chunk.readableSize]
 }
 
+// Pool is the pool of chunk buffers a ParallelReader copies chunks into for its
+// workers. It's a single channel shared by all of them rather than one per
+// worker. Usually the workers only return buffers to it, and one goroutine
+// borrows them: the scanner, Reverse's reader or, with Prefetch, the goroutine
+// reading ahead. ReadZip's workers borrow a buffer each to decompress into,
+// and concurrent calls to ReadWith share the pool, so those can contend.
+// ParallelScan, where each section has a scanner of its own, doesn't use it at
+// all.
 type Pool struct {
+	// If set, called with each buffer as it's borrowed from or returned to the
+	// pool, for tracking down leaked buffers: by the end of a read, every
+	// borrowed buffer should have been returned. They're called concurrently
+	// from the scanner and workers. Buffers put in the pool by Fill, and
+	// buffers of the wrong size passed to Return, aren't reported.
+	OnBorrow func(buf []byte)
+	OnReturn func(buf []byte)
+
 	pool       chan []byte
 	bufferSize int
+	alignment  int
+	counts     *poolCounts
+	poison     bool
 }
 
+// poisonByte is what PoisonBuffers fills returned buffers with.
+const poisonByte = 0xDE
+
 func NewPool(max int, bufferSize int) *Pool {
 	return &Pool{
 		pool:       make(chan []byte, max),
@@ -191,14 +1763,70 @@ func (p *Pool) Borrow() []byte {
 	// block (i.e. it's empty)
 	select {
 	case c = <-p.pool:
+		if p.counts != nil {
+			atomic.AddInt64(&p.counts.hits, 1)
+		}
 	default:
 		// If no buffer is available, make a new one
-		c = make([]byte, p.bufferSize)
+		c = p.alloc(p.bufferSize)
+		if p.counts != nil {
+			atomic.AddInt64(&p.counts.misses, 1)
+		}
+	}
+	if p.OnBorrow != nil {
+		p.OnBorrow(c)
 	}
 	return c
 }
 
+// alloc returns a new buffer of size bytes, starting at a multiple of the
+// pool's alignment, if it has one. Go's heap objects don't move, so the buffer
+// stays aligned for as long as it's in use.
+func (p *Pool) alloc(size int) []byte {
+	if p.alignment <= 1 {
+		return make([]byte, size)
+	}
+	buf := make([]byte, size+p.alignment-1)
+	start := 0
+	if rem := int(uintptr(unsafe.Pointer(unsafe.SliceData(buf))) % uintptr(p.alignment)); rem > 0 {
+		start = p.alignment - rem
+	}
+	return buf[start : start+size : start+size]
+}
+
+// aligned reports whether buf starts at a multiple of the pool's alignment.
+func (p *Pool) aligned(buf []byte) bool {
+	return p.alignment <= 1 || len(buf) == 0 ||
+		uintptr(unsafe.Pointer(unsafe.SliceData(buf)))%uintptr(p.alignment) == 0
+}
+
+// Fill allocates buffers until the pool is full.
+func (p *Pool) Fill() {
+	for len(p.pool) < cap(p.pool) {
+		select {
+		case p.pool <- p.alloc(p.bufferSize):
+		default:
+			return
+		}
+	}
+}
+
 func (p *Pool) Return(c []byte) {
+	// Buffers of a different size, like those allocated for records longer
+	// than ChunkSize or by CoalesceFinal, don't belong in the pool, and nor do
+	// buffers that aren't aligned, which it didn't allocate.
+	if len(c) != p.bufferSize || !p.aligned(c) {
+		return
+	}
+	if p.OnReturn != nil {
+		p.OnReturn(c)
+	}
+	if p.poison {
+		for i := range c {
+			c[i] = poisonByte
+		}
+	}
+
 	// select will go to the default case if sending to the channel would block
 	// (i.e. it's full)
 	select {
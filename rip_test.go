@@ -1,8 +1,20 @@
 package rip
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/iotest"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -113,6 +125,840 @@ func TestRead(t *testing.T) {
 		assert.Len(results, 1)
 		assert.EqualValues([]string{"<FOO>hijklmnop</FOO>"}, results)
 	})
+
+	t.Run("when ChunkBoundaryStart and ChunkBoundary are the same marker", func(t *testing.T) {
+		read := func(r *ParallelReader, input string) []string {
+			chunks := make(chan string, 128)
+			r.Read(strings.NewReader(input), func(chunk []byte) {
+				chunks <- string(chunk)
+			})
+			close(chunks)
+			return drain(chunks)
+		}
+
+		r := NewParallelReader()
+		r.ChunkSize = 5
+		r.ChunkBoundaryStart = "#"
+		r.ChunkBoundary = "#"
+
+		assert.ElementsMatch([]string{"#rec1", "#rec2", "#rec3"}, read(r, "#rec1#rec2#rec3#"))
+		assert.ElementsMatch([]string{"#rec1", "#rec2", "#rec3"}, read(r, "junk#rec1#rec2#rec3"))
+
+		// Records are grouped into chunks of up to ChunkSize like any others, and
+		// a record longer than that gets a chunk of its own.
+		r.ChunkSize = 8
+		assert.ElementsMatch([]string{"#a#b#c", "#longer", "#d"}, read(r, "#a#b#c#longer#d#"))
+
+		r.RequireBoundary = true
+		assert.ElementsMatch([]string{"#a#b#c"}, read(r, "#a#b#c#unterminated"))
+	})
+
+	t.Run("when ChunkBoundaryStart and ChunkBoundary are the same and reads are small", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.ChunkBoundaryStart = "\f"
+		r.ChunkBoundary = "\f"
+
+		pages := "\fpage one\fp2\fp3\fpage four\f"
+		chunks := make(chan string, 128)
+		r.Read(oneByteReader(pages), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"\fpage one", "\fp2\fp3", "\fpage four"}, drain(chunks))
+	})
+
+	t.Run("with ChunkBoundaries mixing single and multichar separators", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 6
+		r.ChunkBoundaries = []string{";", "\n", "END"}
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("ab;cdEND\nfghENDij;k\nlm"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"ab;", "cdEND\n", "fghEND", "ij;k\n", "lm"}, drain(chunks))
+	})
+
+	t.Run("with AutoChunkSize", func(t *testing.T) {
+		r := NewParallelReader()
+		r.AutoChunkSize = true
+		r.RecordsPerChunk = 10
+
+		chunks := make(chan string, 16384)
+		r.Read(strings.NewReader(strings.Repeat("123456789\n", 10000)), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		results := drain(chunks)
+
+		// After calibration, every chunk should hold about 10 records.
+		assert.Greater(len(results), 900)
+		for _, chunk := range results {
+			assert.Equal("123456789\n", chunk[len(chunk)-10:])
+		}
+	})
+
+	t.Run("with LockWorkerThreads", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.LockWorkerThreads = true
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("abc\ndef\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+	})
+
+	t.Run("when the input ends exactly on a ChunkBoundary", func(t *testing.T) {
+		r := NewParallelReader()
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("abc\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.EqualValues([]string{"abc\n"}, drain(chunks))
+	})
+
+	t.Run("when using EmitEmptyFinalChunk", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.EmitEmptyFinalChunk = true
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("abc\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abc\n", ""}, drain(chunks))
+	})
+
+	t.Run("with a record longer than ChunkSize", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		long := strings.Repeat("x", 20) + "\n"
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("abc\n"+long+"def\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abc\n", long, "def\n"}, drain(chunks))
+	})
+
+	t.Run("with a record longer than MaxChunkSize", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.MaxChunkSize = 8
+
+		assert.PanicsWithError(bufio.ErrTooLong.Error(), func() {
+			r.Read(strings.NewReader("abc\n"+strings.Repeat("x", 20)+"\n"), func(chunk []byte) {})
+		})
+	})
+
+	t.Run("with FirstBoundary", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.FirstBoundary = "\n\n"
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("a: 1\nb: 2\n\nabc\ndef\nghi\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		// The header is longer than ChunkSize, but is still split at FirstBoundary.
+		assert.ElementsMatch([]string{"a: 1\nb: 2\n\n", "abc\ndef\n", "ghi\n"}, drain(chunks))
+	})
+
+	t.Run("with FirstBoundary and a stream without one", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.FirstBoundary = "\n\n"
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("abc\ndef\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+	})
+
+	t.Run("with Filter", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.Filter = func(chunk []byte) bool {
+			return chunk[0] != '#'
+		}
+
+		chunks := make(chan string, 128)
+		result := r.Read(strings.NewReader("abc\n#no\ndef\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+		assert.EqualValues(2, result.Stats.Chunks)
+	})
+
+	t.Run("with SampleEvery", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 2
+		r.SampleEvery = 3
+
+		chunks := make(chan string, 128)
+		result := r.Read(strings.NewReader("0\n1\n2\n3\n4\n5\n6\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"0\n", "3\n", "6\n"}, drain(chunks))
+		assert.EqualValues(3, result.Stats.Chunks)
+	})
+
+	t.Run("with SampleRate", func(t *testing.T) {
+		sample := func(seed int64) []string {
+			r := NewParallelReader()
+			r.ChunkSize = 5
+			r.SampleRate = 0.25
+			r.SampleSeed = seed
+
+			var input strings.Builder
+			for i := 0; i < 10000; i++ {
+				fmt.Fprintf(&input, "%04d\n", i)
+			}
+
+			chunks := make(chan string, 10000)
+			r.Read(strings.NewReader(input.String()), func(chunk []byte) {
+				chunks <- string(chunk)
+			})
+			close(chunks)
+			return drain(chunks)
+		}
+
+		chunks := sample(1)
+		assert.InDelta(2500, len(chunks), 250)
+		assert.ElementsMatch(chunks, sample(1))
+		assert.NotEqual(len(chunks), len(sample(2)))
+	})
+
+	t.Run("when using CoalesceFinal", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.CoalesceFinal = true
+		r.MinChunkSize = 4
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("abcdef\nab\nabcdef\nab\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abcdef\n", "ab\n", "abcdef\nab\n"}, drain(chunks))
+	})
+
+	t.Run("when using CoalesceFinal and the final chunk is large enough", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.CoalesceFinal = true
+		r.MinChunkSize = 4
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("abcdef\nabcdef\nabcd\n"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abcdef\n", "abcdef\n", "abcd\n"}, drain(chunks))
+	})
+}
+
+func TestReadFixed(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("from a pipe", func(t *testing.T) {
+		pr, pw, err := os.Pipe()
+		if !assert.NoError(err) {
+			return
+		}
+		defer pr.Close()
+
+		go func() {
+			pw.Write([]byte("abcdefghij"))
+			pw.Close()
+		}()
+
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		chunks := make(chan string, 128)
+		r.ReadFixed(pr, func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abcd", "efgh", "ij"}, drain(chunks))
+	})
+
+	t.Run("fills every chunk but the last however reads are sized", func(t *testing.T) {
+		for _, prefetch := range []int{0, 4} {
+			r := NewParallelReader()
+			r.ChunkSize = 64
+			r.Prefetch = prefetch
+
+			var mu sync.Mutex
+			sizes := map[int]int{}
+			r.ReadFixed(iotest.HalfReader(strings.NewReader(strings.Repeat("a", 64*50+10))), func(chunk []byte) {
+				mu.Lock()
+				sizes[len(chunk)]++
+				mu.Unlock()
+			})
+
+			assert.Equal(map[int]int{64: 50, 10: 1}, sizes, "with Prefetch %d", prefetch)
+		}
+	})
+}
+
+func TestReadWithBoundary(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewParallelReader()
+	r.ChunkSize = 5
+	r.ChunkBoundaries = []string{"\n", "\r\n"}
+
+	chunks := make(chan string, 128)
+	r.ReadWithBoundary(strings.NewReader("abc\r\ndef\nghi"), func(chunk []byte, boundary []byte) {
+		chunks <- fmt.Sprintf("%q %q", chunk, boundary)
+	})
+	close(chunks)
+
+	assert.ElementsMatch([]string{
+		`"abc\r\n" "\r\n"`,
+		`"def\n" "\n"`,
+		`"ghi" ""`,
+	}, drain(chunks))
+}
+
+func TestReadLineNumbered(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewParallelReader()
+	r.ChunkSize = 8
+
+	chunks := make(chan string, 128)
+	r.ReadLineNumbered(strings.NewReader("a\nb\nc\nlonger\nd\n"), func(startLine int, chunk []byte) {
+		chunks <- fmt.Sprintf("%d:%s", startLine, chunk)
+	})
+	close(chunks)
+
+	assert.ElementsMatch([]string{"1:a\nb\nc\n", "4:longer\n", "5:d\n"}, drain(chunks))
+}
+
+func TestReadSerial(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewParallelReader()
+	r.ChunkSize = 4
+
+	// Appending without locking is safe with a single worker.
+	var chunks []string
+	r.ReadSerial(strings.NewReader(strings.Repeat("abc\ndef\n", 100)), func(chunk []byte) {
+		chunks = append(chunks, string(chunk))
+	})
+
+	assert.Len(chunks, 200)
+	for i, chunk := range chunks {
+		assert.Equal([]string{"abc\n", "def\n"}[i%2], chunk)
+	}
+	assert.Equal(runtime.NumCPU(), r.Concurrency)
+}
+
+func TestReadFull(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewParallelReader()
+	r.ChunkSize = 8
+
+	chunks := make(chan string, 128)
+	r.ReadFull(strings.NewReader("abc\ndef\n"), func(buf []byte, readableSize int) {
+		assert.Len(buf, 8)
+		chunks <- string(buf[:readableSize])
+	})
+	close(chunks)
+
+	assert.Equal([]string{"abc\ndef\n"}, drain(chunks))
+}
+
+func TestWarm(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewParallelReader()
+	r.Concurrency = 2
+	r.PoolSize = 4
+	r.ChunkSize = 16
+	r.Warm()
+
+	assert.Len(r.pool.pool, 4)
+
+	// The warmed pool is used by the next read rather than replaced.
+	pool := r.pool
+	r.Read(strings.NewReader("abc\n"), func(chunk []byte) {})
+	assert.Same(pool, r.pool)
+	assert.Len(r.pool.pool, 4)
+}
+
+func TestPool(t *testing.T) {
+	assert := assert.New(t)
+
+	input := strings.Repeat("abc\n", 1000) + "x\n"
+
+	reads := map[string]func(r *ParallelReader){
+		"Read": func(r *ParallelReader) {
+			r.Read(strings.NewReader(input), func(chunk []byte) {})
+		},
+		"Read with CoalesceFinal": func(r *ParallelReader) {
+			r.CoalesceFinal = true
+			r.MinChunkSize = 4
+			r.Read(strings.NewReader(input), func(chunk []byte) {})
+		},
+		"ReadContextWork stopped early": func(r *ParallelReader) {
+			r.ReadContextWork(context.Background(), strings.NewReader(input), func(ctx context.Context, chunk []byte) error {
+				return Stop
+			})
+		},
+	}
+
+	for name, read := range reads {
+		t.Run(name, func(t *testing.T) {
+			r := NewParallelReader()
+			r.ChunkSize = 8
+
+			var borrowed, returned int64
+			pool := r.Pool()
+			pool.OnBorrow = func(buf []byte) { atomic.AddInt64(&borrowed, 1) }
+			pool.OnReturn = func(buf []byte) { atomic.AddInt64(&returned, 1) }
+
+			read(r)
+
+			assert.Positive(borrowed)
+			assert.Equal(borrowed, returned)
+		})
+	}
+}
+
+func TestPoisonBuffers(t *testing.T) {
+	assert := assert.New(t)
+
+	// Illegally keeps the final chunk, whose buffer is returned to the pool once
+	// the callback returns, and no chunk is left to reuse it.
+	kept := func(r *ParallelReader) []byte {
+		var final []byte
+		r.ReadMeta(strings.NewReader(strings.Repeat("abc\n", 100)+"x\n"), func(info ChunkInfo) {
+			if info.Final {
+				final = info.Bytes
+			}
+		})
+		return final
+	}
+
+	t.Run("overwrites returned buffers", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.PoisonBuffers = true
+
+		assert.Equal([]byte{0xDE, 0xDE}, kept(r))
+	})
+
+	t.Run("with NoCopy", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.PoisonBuffers = true
+		r.NoCopy = true
+
+		assert.Equal([]byte{0xDE, 0xDE}, kept(r))
+	})
+
+	t.Run("without PoisonBuffers", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+
+		assert.Equal("x\n", string(kept(r)))
+	})
+
+	t.Run("when set after a read", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		pool := r.Pool()
+		kept(r)
+
+		r.PoisonBuffers = true
+		assert.Equal([]byte{0xDE, 0xDE}, kept(r))
+		assert.NotSame(pool, r.Pool())
+		assert.False(pool.poison)
+	})
+}
+
+func TestBufferAlignment(t *testing.T) {
+	assert := assert.New(t)
+
+	// Records of uneven sizes, some longer than ChunkSize, and a short final
+	// chunk for CoalesceFinal to merge.
+	var b strings.Builder
+	for i := 0; i < 500; i++ {
+		b.WriteString(strings.Repeat("a", 1+i%40) + "\n")
+	}
+	b.WriteString("z\n")
+	input := b.String()
+
+	configs := map[string]func(r *ParallelReader){
+		"with the defaults": func(r *ParallelReader) {},
+		"with NoCopy":       func(r *ParallelReader) { r.NoCopy = true },
+		"with CoalesceFinal": func(r *ParallelReader) {
+			r.CoalesceFinal = true
+			r.MinChunkSize = 8
+		},
+		"with ParallelScan": func(r *ParallelReader) { r.ParallelScan = true },
+	}
+
+	for name, configure := range configs {
+		t.Run(name, func(t *testing.T) {
+			r := NewParallelReader()
+			r.ChunkSize = 32
+			r.BufferAlignment = 64
+			configure(r)
+
+			var unaligned, bytes int64
+			r.Read(strings.NewReader(input), func(chunk []byte) {
+				if uintptr(unsafe.Pointer(unsafe.SliceData(chunk)))%64 != 0 {
+					atomic.AddInt64(&unaligned, 1)
+				}
+				atomic.AddInt64(&bytes, int64(len(chunk)))
+			})
+
+			assert.Zero(unaligned)
+			assert.EqualValues(len(input), bytes)
+		})
+	}
+
+	t.Run("with ReadFixed", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 100
+		r.BufferAlignment = 4096
+
+		var unaligned int64
+		r.ReadFixed(strings.NewReader(input), func(chunk []byte) {
+			if uintptr(unsafe.Pointer(unsafe.SliceData(chunk)))%4096 != 0 {
+				atomic.AddInt64(&unaligned, 1)
+			}
+		})
+		assert.Zero(unaligned)
+	})
+
+	t.Run("with WithBufferAlignment", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 32
+
+		var unaligned int64
+		r.ReadWith(strings.NewReader(input), func(chunk []byte) {
+			if uintptr(unsafe.Pointer(unsafe.SliceData(chunk)))%64 != 0 {
+				atomic.AddInt64(&unaligned, 1)
+			}
+		}, WithBufferAlignment(64))
+		assert.Zero(unaligned)
+		assert.Zero(r.BufferAlignment)
+	})
+}
+
+func TestScanChunksWithBoundary(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("with ChunkBoundaries that could straddle the end of the data", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ChunkBoundaries = []string{"\r", "\r\n"}
+
+		// The trailing "\r" may turn out to be the start of "\r\n", so the split
+		// happens at the earlier "\r".
+		advance, token, err := r.ScanChunksWithBoundary([]byte("a\rb\r"), false)
+		assert.NoError(err)
+		assert.Equal(2, advance)
+		assert.Equal("a\r", string(token))
+
+		// At EOF there's no more data coming, so the trailing "\r" is a boundary.
+		advance, token, err = r.ScanChunksWithBoundary([]byte("a\rb\r"), true)
+		assert.NoError(err)
+		assert.Equal(4, advance)
+		assert.Equal("a\rb\r", string(token))
+	})
+
+	t.Run("without a ChunkBoundary", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ChunkBoundary = ""
+
+		advance, token, err := r.ScanChunksWithBoundary([]byte("abcdef"), false)
+		assert.NoError(err)
+		assert.Equal(4, advance)
+		assert.Equal("abcd", string(token))
+
+		advance, token, err = r.ScanChunksWithBoundary([]byte("ef"), false)
+		assert.NoError(err)
+		assert.Zero(advance)
+		assert.Nil(token)
+
+		advance, token, err = r.ScanChunksWithBoundary([]byte("ef"), true)
+		assert.NoError(err)
+		assert.Equal(2, advance)
+		assert.Equal("ef", string(token))
+
+		advance, token, err = r.ScanChunksWithBoundary(nil, true)
+		assert.NoError(err)
+		assert.Zero(advance)
+		assert.Nil(token)
+
+		chunks := make(chan string, 128)
+		r.Read(strings.NewReader("abcdefghij"), func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+		assert.ElementsMatch([]string{"abcd", "efgh", "ij"}, drain(chunks))
+	})
+
+	t.Run("with no ChunkBoundaryStart before the boundary", func(t *testing.T) {
+		for _, noCopy := range []bool{false, true} {
+			r := NewParallelReader()
+			r.ChunkSize = 8
+			r.ChunkBoundaryStart = "<a>"
+			r.ChunkBoundary = "</a>"
+			r.NoCopy = noCopy
+
+			advance, token, err := r.ScanChunksWithBoundary([]byte("junkjunkjunk</a><a>x</a>"), false)
+			assert.NoError(err)
+			assert.Equal(16, advance)
+			assert.Nil(token)
+
+			chunks := make(chan string, 128)
+			r.Read(strings.NewReader("junkjunkjunk</a><a>x</a>"), func(chunk []byte) {
+				chunks <- string(chunk)
+			})
+			close(chunks)
+			assert.Equal([]string{"<a>x</a>"}, drain(chunks))
+		}
+	})
+
+	t.Run("with an empty candidate in ChunkBoundaries", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 6
+		r.ChunkBoundaries = []string{"", ";"}
+
+		advance, token, err := r.ScanChunksWithBoundary([]byte("ab;cdef"), false)
+		assert.NoError(err)
+		assert.Equal(3, advance)
+		assert.Equal("ab;", string(token))
+	})
+
+	t.Run("with a record longer than ChunkSize", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ChunkBoundaries = []string{"\r", "\r\n"}
+
+		// The chunk ends at the first boundary, preferring "\r\n" over the "\r"
+		// at the same position.
+		advance, token, err := r.ScanChunksWithBoundary([]byte("abcdef\r\nab\r"), false)
+		assert.NoError(err)
+		assert.Equal(8, advance)
+		assert.Equal("abcdef\r\n", string(token))
+	})
+}
+
+func TestDebugSplit(t *testing.T) {
+	assert := assert.New(t)
+
+	type call struct {
+		dataLen  int
+		atEOF    bool
+		advance  int
+		tokenLen int
+	}
+
+	r := NewParallelReader()
+	r.ChunkSize = 8
+
+	var calls []call
+	r.DebugSplit = func(dataLen int, atEOF bool, advance int, tokenLen int) {
+		calls = append(calls, call{dataLen, atEOF, advance, tokenLen})
+	}
+	r.Read(oneByteReader("abc\ndefgh\nij"), func(chunk []byte) {})
+
+	// Nothing is split until ChunkSize bytes have been read, and then the chunk
+	// is cut at the last boundary before it.
+	for i := 1; i < 8; i++ {
+		assert.Contains(calls, call{i, false, 0, -1})
+	}
+	assert.Contains(calls, call{8, false, 4, 4})
+
+	// The final chunk takes the rest of the data without advancing over it.
+	assert.Equal(call{2, true, 0, 2}, calls[len(calls)-1])
+}
+
+func TestBoundaryFunc(t *testing.T) {
+	assert := assert.New(t)
+
+	// Ends records where a top-level object is balanced, which no single
+	// boundary string could find.
+	balanced := func(data []byte, atEOF bool) int {
+		depth := 0
+		for i, b := range data {
+			switch b {
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return -1
+	}
+
+	read := func(r *ParallelReader, stream io.Reader) []string {
+		chunks := make(chan string, 128)
+		r.Read(stream, func(chunk []byte) {
+			chunks <- string(chunk)
+		})
+		close(chunks)
+		return drain(chunks)
+	}
+
+	input := "{a}{b{c}}{d{e{f}}}{ghijklmnop}{q}"
+
+	t.Run("splits where BoundaryFunc says", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 10
+		r.BoundaryFunc = balanced
+
+		assert.ElementsMatch([]string{"{a}{b{c}}", "{d{e{f}}}", "{ghijklmnop}", "{q}"}, read(r, strings.NewReader(input)))
+	})
+
+	t.Run("with fragmented reads", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 10
+		r.BoundaryFunc = balanced
+
+		assert.ElementsMatch(read(r, strings.NewReader(input)), read(r, oneByteReader(input)))
+	})
+
+	t.Run("with an unbalanced tail", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 10
+		r.BoundaryFunc = balanced
+
+		assert.ElementsMatch([]string{"{a}{b{c}}", "{d{e"}, read(r, strings.NewReader("{a}{b{c}}{d{e")))
+
+		r.RequireBoundary = true
+		assert.ElementsMatch([]string{"{a}{b{c}}"}, read(r, strings.NewReader("{a}{b{c}}{d{e")))
+	})
+
+	t.Run("with ParallelScan", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 10
+		r.BoundaryFunc = balanced
+		r.ParallelScan = true
+
+		assert.ElementsMatch([]string{"{a}{b{c}}", "{d{e{f}}}", "{ghijklmnop}", "{q}"}, read(r, strings.NewReader(input)))
+	})
+
+	t.Run("when BoundaryFunc returns an index past the data", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 10
+		r.BoundaryFunc = func(data []byte, atEOF bool) int {
+			return len(data) + 1
+		}
+
+		assert.PanicsWithError(bufio.ErrAdvanceTooFar.Error(), func() {
+			r.Read(strings.NewReader(input), func(chunk []byte) {})
+		})
+	})
+}
+
+func TestMinParallelSize(t *testing.T) {
+	assert := assert.New(t)
+
+	// read reports whether the callback was called on the goroutine that called
+	// Read, which is the only one whose stack includes it.
+	read := func(r *ParallelReader, stream io.Reader) (chunks []string, inline bool) {
+		out := make(chan string, 1024)
+		inline = true
+		var mu sync.Mutex
+		r.Read(stream, func(chunk []byte) {
+			buf := make([]byte, 64<<10)
+			onCaller := strings.Contains(string(buf[:runtime.Stack(buf, false)]), "(*ParallelReader).Read(")
+			mu.Lock()
+			inline = inline && onCaller
+			mu.Unlock()
+			out <- string(chunk)
+		})
+		close(out)
+		return drain(out), inline
+	}
+
+	newReader := func() *ParallelReader {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.MinParallelSize = 16
+		return r
+	}
+
+	small := "abc\ndef\nghi\n"
+	large := strings.Repeat("abc\n", 100)
+
+	t.Run("when the stream has a Len", func(t *testing.T) {
+		chunks, inline := read(newReader(), strings.NewReader(small))
+		assert.True(inline)
+		assert.Equal([]string{"abc\n", "def\n", "ghi\n"}, chunks)
+
+		chunks, inline = read(newReader(), strings.NewReader(large))
+		assert.False(inline)
+		assert.Len(chunks, 100)
+	})
+
+	t.Run("when the stream's size is unknown", func(t *testing.T) {
+		chunks, inline := read(newReader(), oneByteReader(small))
+		assert.True(inline)
+		assert.Equal([]string{"abc\n", "def\n", "ghi\n"}, chunks)
+
+		// What was read to measure the stream is still delivered.
+		chunks, inline = read(newReader(), oneByteReader(large))
+		assert.False(inline)
+		assert.Len(chunks, 100)
+		assert.Equal(large, strings.Join(sortedStrings(chunks), ""))
+	})
+
+	t.Run("when the stream is a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "input.txt")
+		os.WriteFile(path, []byte(small), 0644)
+
+		f, err := os.Open(path)
+		assert.NoError(err)
+		defer f.Close()
+
+		chunks, inline := read(newReader(), f)
+		assert.True(inline)
+		assert.Len(chunks, 3)
+	})
 }
 
 func drain(c <-chan string) []string {
@@ -122,3 +968,235 @@ func drain(c <-chan string) []string {
 	}
 	return results
 }
+
+func TestFragmentedReads(t *testing.T) {
+	assert := assert.New(t)
+
+	// Records of assorted lengths, including one far longer than ChunkSize.
+	input := "a\nbcd\nefghij\n" + strings.Repeat("k", 100) + "\nlm\r\nnopq\r\n" + strings.Repeat("rstu\n", 20) + "unterminated"
+
+	// Scanned chunks, in order of their offsets.
+	scanned := func(r *ParallelReader, stream io.Reader) []string {
+		var mu sync.Mutex
+		chunks := map[int64]string{}
+		r.run(stream, nil, func(c *chunk) {
+			mu.Lock()
+			defer mu.Unlock()
+			chunks[c.offset] = string(c.ReadableBytes())
+		})
+
+		offsets := make([]int64, 0, len(chunks))
+		for offset := range chunks {
+			offsets = append(offsets, offset)
+		}
+		sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+		var ordered []string
+		for _, offset := range offsets {
+			ordered = append(ordered, chunks[offset])
+		}
+		return ordered
+	}
+
+	configs := map[string]func(r *ParallelReader){
+		"with the defaults":         func(r *ParallelReader) {},
+		"with a long boundary":      func(r *ParallelReader) { r.ChunkBoundary = "\r\n" },
+		"with ChunkBoundaries":      func(r *ParallelReader) { r.ChunkBoundaries = []string{"\n", "\r\n", "\r"} },
+		"with RequireBoundary":      func(r *ParallelReader) { r.RequireBoundary = true },
+		"with FirstBoundary":        func(r *ParallelReader) { r.FirstBoundary = "j\n" },
+		"with AutoChunkSize":        func(r *ParallelReader) { r.AutoChunkSize = true; r.RecordsPerChunk = 2 },
+		"with CoalesceFinal":        func(r *ParallelReader) { r.CoalesceFinal = true; r.MinChunkSize = 8 },
+		"without a boundary":        func(r *ParallelReader) { r.ChunkBoundary = "" },
+		"with a small MaxChunkSize": func(r *ParallelReader) { r.MaxChunkSize = 200 },
+	}
+
+	for name, configure := range configs {
+		t.Run(name, func(t *testing.T) {
+			r := NewParallelReader()
+			r.ChunkSize = 16
+			configure(r)
+
+			expected := scanned(r, strings.NewReader(input))
+			assert.NotEmpty(expected)
+			assert.Equal(expected, scanned(r, oneByteReader(input)))
+		})
+	}
+
+	t.Run("with ReadFixed", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+
+		read := func(stream io.Reader) []string {
+			chunks := make([]string, (len(input)+r.ChunkSize-1)/r.ChunkSize)
+			r.ReadFixed(stream, func(chunk []byte) {
+				// Every chunk but the last is full, so its position is known from its
+				// contents' position in the input.
+				chunks[strings.Index(input, string(chunk))/r.ChunkSize] = string(chunk)
+			})
+			return chunks
+		}
+
+		assert.Equal(read(strings.NewReader(input)), read(oneByteReader(input)))
+	})
+
+	t.Run("with ReadRegions", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.ChunkBoundaryStart = "{{"
+		r.ChunkBoundary = "}}"
+
+		input := "Hello {{name}}, you owe {{amount}}{{currency}}. {{unclosed"
+		read := func(stream io.Reader) []string {
+			chunks := make(chan string, 128)
+			r.ReadRegions(stream, func(chunk []byte, inside bool) {
+				chunks <- fmt.Sprintf("%t:%s", inside, chunk)
+			})
+			close(chunks)
+			return drain(chunks)
+		}
+
+		assert.ElementsMatch(read(strings.NewReader(input)), read(oneByteReader(input)))
+	})
+
+	t.Run("with a record much longer than ChunkSize", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 1 << 10
+		r.MaxChunkSize = 1 << 20
+
+		// Searching everything buffered for a boundary after every byte would take
+		// minutes for a record this long.
+		long := strings.Repeat("x", 1<<19) + "\n"
+		var chunks int64
+		result := r.Read(oneByteReader(long), func(chunk []byte) {
+			atomic.AddInt64(&chunks, 1)
+			assert.Len(chunk, len(long))
+		})
+
+		assert.True(result.Completed)
+		assert.EqualValues(1, chunks)
+	})
+}
+
+func TestOversizedRecords(t *testing.T) {
+	assert := assert.New(t)
+
+	// Records mostly shorter than ChunkSize, with every so often one up to four
+	// times as long, each filled with its own letter so that a truncated chunk,
+	// or one overwritten through a shared buffer, doesn't match the input.
+	var b strings.Builder
+	for i := 0; i < 2000; i++ {
+		size := 1 + i%12
+		if i%7 == 0 {
+			size = 16 + i%48
+		}
+		b.WriteString(strings.Repeat(string(rune('a'+i%26)), size) + "\n")
+	}
+	input := b.String()
+
+	configs := map[string]func(r *ParallelReader){
+		"with the defaults": func(r *ParallelReader) {},
+		"with NoCopy":       func(r *ParallelReader) { r.NoCopy = true },
+		"with ParallelScan": func(r *ParallelReader) { r.ParallelScan = true },
+	}
+
+	for name, configure := range configs {
+		t.Run(name, func(t *testing.T) {
+			r := NewParallelReader()
+			r.Concurrency = 4
+			r.ChunkSize = 16
+			r.MaxChunkSize = 64
+			configure(r)
+
+			var bytes, oversized int64
+			result := r.ReadMeta(strings.NewReader(input), func(info ChunkInfo) {
+				assert.Equal(input[info.Offset:info.Offset+int64(len(info.Bytes))], string(info.Bytes))
+				assert.True(strings.HasSuffix(string(info.Bytes), "\n"))
+				if len(info.Bytes) > r.ChunkSize {
+					atomic.AddInt64(&oversized, 1)
+				}
+
+				// Scribble over the chunk, as a worker is free to, which would show up
+				// in another chunk if their buffers were shared.
+				for i := range info.Bytes {
+					info.Bytes[i] = '?'
+				}
+				atomic.AddInt64(&bytes, int64(len(info.Bytes)))
+			})
+
+			assert.True(result.Completed)
+			assert.EqualValues(len(input), bytes)
+			assert.Positive(oversized)
+		})
+	}
+}
+
+// oneByteReader returns a reader of s that returns a single byte from each
+// read, like a pipe or network stream delivering data in the smallest possible
+// pieces.
+func oneByteReader(s string) io.Reader {
+	return iotest.OneByteReader(strings.NewReader(s))
+}
+
+func TestLookahead(t *testing.T) {
+	assert := assert.New(t)
+
+	read := func(r *ParallelReader, stream io.Reader) []string {
+		var mu sync.Mutex
+		chunks := map[int]string{}
+		result := r.ReadMeta(stream, func(info ChunkInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			chunks[info.Index] = fmt.Sprintf("%d:%s", info.Offset, info.Bytes)
+		})
+		assert.NoError(result.Err)
+
+		ordered := make([]string, len(chunks))
+		for index, chunk := range chunks {
+			ordered[index] = chunk
+		}
+		return ordered
+	}
+
+	t.Run("delivers the bytes after each chunk with it", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 6
+		r.Lookahead = 2
+
+		assert.Equal([]string{"0:ab\ncd", "3:cd\nef", "6:ef\n"}, read(r, strings.NewReader("ab\ncd\nef\n")))
+	})
+
+	t.Run("with one byte reads", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 6
+		r.Lookahead = 2
+
+		assert.Equal([]string{"0:ab\ncd", "3:cd\nef", "6:ef\n"}, read(r, oneByteReader("ab\ncd\nef\n")))
+	})
+
+	t.Run("when less than Lookahead is left", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.Lookahead = 4
+
+		assert.Equal([]string{"0:ab\ncd\n", "3:cd\n"}, read(r, strings.NewReader("ab\ncd\n")))
+	})
+
+	t.Run("with CoalesceFinal", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 6
+		r.Lookahead = 2
+		r.CoalesceFinal = true
+		r.MinChunkSize = 4
+
+		assert.Equal([]string{"0:ab\ncd", "3:cd\nef\n"}, read(r, strings.NewReader("ab\ncd\nef\n")))
+	})
+
+	t.Run("with Reverse", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 6
+		r.Lookahead = 2
+		r.Reverse = true
+
+		assert.Equal([]string{"6:ef\n", "3:cd\nef", "0:ab\ncd"}, read(r, strings.NewReader("ab\ncd\nef\n")))
+	})
+}
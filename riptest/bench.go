@@ -0,0 +1,34 @@
+package riptest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/brentd/rip"
+)
+
+// BenchmarkReader runs a benchmark of r reading input with work, reporting
+// throughput in MB/s and allocations per read. It's meant to be called from
+// the benchmarks of packages that use rip, to compare configurations on their
+// own data:
+//
+//	func BenchmarkParse(b *testing.B) {
+//		r := rip.NewParallelReader()
+//		r.ChunkSize = 1 << 20
+//		riptest.BenchmarkReader(b, r, input, parse)
+//	}
+//
+// Chunks are assigned to workers nondeterministically, so for results that are
+// more reproducible from run to run, compare against a reader with a
+// Concurrency of 1, which behaves like ReadSerial.
+func BenchmarkReader(b *testing.B, r *rip.ParallelReader, input []byte, work func(chunk []byte)) {
+	b.Helper()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	r.Warm()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.Read(bytes.NewReader(input), work)
+	}
+}
@@ -0,0 +1,23 @@
+package riptest
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/brentd/rip"
+)
+
+func BenchmarkRead(b *testing.B) {
+	input := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog\n"), 1<<14)
+
+	for _, concurrency := range []int{1, 4} {
+		b.Run(fmt.Sprintf("Concurrency=%d", concurrency), func(b *testing.B) {
+			r := rip.NewParallelReader()
+			r.Concurrency = concurrency
+			r.ChunkSize = 4 << 10
+
+			BenchmarkReader(b, r, input, func(chunk []byte) {})
+		})
+	}
+}
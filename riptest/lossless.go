@@ -0,0 +1,65 @@
+package riptest
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/brentd/rip"
+)
+
+// AssertLossless reads input with r, and fails t unless the chunks, put back
+// in stream order, reproduce input exactly, with each chunk starting at the
+// offset where the one before it ended. It's meant to be called from the tests
+// of packages that depend on rip for faithful stream transformation, to check
+// that their configuration of r has it.
+//
+// Splitting on ChunkBoundary or ChunkBoundaries, or into fixed size chunks, is
+// lossless, as are FirstBoundary, CoalesceFinal, AutoChunkSize, NoCopy and
+// Reverse. Some settings change or drop bytes by design: RequireBoundary and
+// MaxBytes drop a trailing partial record; ChunkBoundaryStart drops anything
+// before a start marker (and, when it's the same as ChunkBoundary, the closing
+// marker at the end of the stream); Filter and sampling drop whole chunks;
+// NormalizeLineEndings rewrites line endings; StripBOM removes a byte order
+// mark; SkipToFirstBoundary drops the first record; and Lookahead repeats the
+// start of each chunk at the end of the one before it. ReadRegions is lossless
+// unless RequireBoundary is set.
+func AssertLossless(t testing.TB, input []byte, r *rip.ParallelReader) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var chunks []rip.ChunkInfo
+	result := r.ReadMeta(bytes.NewReader(input), func(info rip.ChunkInfo) {
+		info.Bytes = append([]byte(nil), info.Bytes...)
+		mu.Lock()
+		chunks = append(chunks, info)
+		mu.Unlock()
+	})
+	if result.Err != nil {
+		t.Errorf("rip: read failed: %v", result.Err)
+		return
+	}
+
+	// Chunks are put in stream order by offset rather than index, which is the
+	// order they're delivered in, since with Reverse that's the other way round.
+	slices.SortFunc(chunks, func(a, b rip.ChunkInfo) int { return cmp.Compare(a.Offset, b.Offset) })
+
+	var offset int64
+	for _, info := range chunks {
+		if info.Offset != offset {
+			t.Errorf("rip: chunk %d starts at offset %d, but the chunk before it ended at %d", info.Index, info.Offset, offset)
+			return
+		}
+		end := offset + int64(len(info.Bytes))
+		if end > int64(len(input)) || !bytes.Equal(info.Bytes, input[offset:end]) {
+			t.Errorf("rip: chunk %d at offset %d doesn't match the input: %q", info.Index, offset, info.Bytes)
+			return
+		}
+		offset = end
+	}
+	if offset != int64(len(input)) {
+		t.Errorf("rip: chunks end at offset %d, but the input is %d bytes: %q is missing", offset, len(input), input[offset:])
+	}
+}
@@ -0,0 +1,56 @@
+package riptest
+
+import (
+	"testing"
+
+	"github.com/brentd/rip"
+)
+
+func TestAssertLossless(t *testing.T) {
+	input := []byte("header\n\nabc\r\ndef\nghiEND\nj;kl\n\nmnop\nqr")
+
+	for name, configure := range map[string]func(r *rip.ParallelReader){
+		"with ChunkBoundary":     func(r *rip.ParallelReader) {},
+		"with ChunkBoundaries":   func(r *rip.ParallelReader) { r.ChunkBoundaries = []string{"\r\n", "\n", "END", ";"} },
+		"without a boundary":     func(r *rip.ParallelReader) { r.ChunkBoundary = "" },
+		"with FirstBoundary":     func(r *rip.ParallelReader) { r.FirstBoundary = "\n\n" },
+		"with a long boundary":   func(r *rip.ParallelReader) { r.ChunkBoundary = "\n\n" },
+		"with CoalesceFinal":     func(r *rip.ParallelReader) { r.CoalesceFinal, r.MinChunkSize = true, 4 },
+		"with AutoChunkSize":     func(r *rip.ParallelReader) { r.AutoChunkSize = true },
+		"with NoCopy":            func(r *rip.ParallelReader) { r.NoCopy = true },
+		"with Reverse":           func(r *rip.ParallelReader) { r.Reverse = true },
+		"with MaxChunkSize":      func(r *rip.ParallelReader) { r.ChunkSize, r.MaxChunkSize = 2, 64 },
+		"case insensitively":     func(r *rip.ParallelReader) { r.ChunkBoundary, r.CaseInsensitiveBoundary = "end", true },
+		"with EmitEmptyFinal":    func(r *rip.ParallelReader) { r.EmitEmptyFinalChunk = true },
+		"with a small ChunkSize": func(r *rip.ParallelReader) { r.ChunkSize = 1 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := rip.NewParallelReader()
+			r.ChunkSize = 8
+			configure(r)
+			AssertLossless(t, input, r)
+		})
+	}
+
+	t.Run("reports a lossy configuration", func(t *testing.T) {
+		r := rip.NewParallelReader()
+		r.ChunkSize = 8
+		r.RequireBoundary = true
+
+		tb := &recordingTB{TB: t}
+		AssertLossless(tb, input, r)
+		if !tb.failed {
+			t.Error("expected a dropped partial record to be reported")
+		}
+	})
+}
+
+// recordingTB records failures rather than failing the test.
+type recordingTB struct {
+	testing.TB
+	failed bool
+}
+
+func (tb *recordingTB) Errorf(format string, args ...any) {
+	tb.failed = true
+}
@@ -0,0 +1,38 @@
+package rip
+
+import "math/rand/v2"
+
+// sampler decides which chunks SampleEvery and SampleRate let through.
+type sampler struct {
+	every int
+	seen  int
+
+	rate float64
+	rng  *rand.Rand
+}
+
+// newSampler returns a sampler for a scan starting at offset in the stream, or
+// nil if every chunk is to be delivered. Each offset gets its own sequence of
+// random numbers, so that ParallelScan's sections sample independently, but
+// repeatably for a given SampleSeed.
+func (r *ParallelReader) newSampler(offset int64) *sampler {
+	s := &sampler{every: r.SampleEvery, rate: r.SampleRate}
+	if s.rate > 0 && s.rate < 1 {
+		s.rng = rand.New(rand.NewPCG(uint64(r.SampleSeed), uint64(offset)))
+	}
+	if s.every <= 1 && s.rng == nil {
+		return nil
+	}
+	return s
+}
+
+// keep reports whether the next chunk should be delivered.
+func (s *sampler) keep() bool {
+	if s.every > 1 {
+		s.seen++
+		if (s.seen-1)%s.every != 0 {
+			return false
+		}
+	}
+	return s.rng == nil || s.rng.Float64() < s.rate
+}
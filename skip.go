@@ -0,0 +1,77 @@
+package rip
+
+import "io"
+
+// skippingToFirstBoundary wraps stream to discard everything up to and
+// including its first boundary if SkipToFirstBoundary is set.
+func (r *ParallelReader) skippingToFirstBoundary(stream io.Reader) io.Reader {
+	// A shared marker begins a record rather than ending one, so the scanner
+	// already skips anything before the first of them.
+	if !r.SkipToFirstBoundary || !r.hasBoundary() || r.sharedMarker() || r.BoundaryFunc != nil {
+		return stream
+	}
+	return &boundarySkipper{r: r, src: stream}
+}
+
+// boundarySkipper is an io.Reader that discards src up to the end of its first
+// boundary, then reads the rest of it unchanged.
+type boundarySkipper struct {
+	r       *ParallelReader
+	src     io.Reader
+	skipped bool
+
+	// What was read past the first boundary while looking for it, and the error
+	// that came with it, to be returned before reading any more of src.
+	pending []byte
+	err     error
+}
+
+func (s *boundarySkipper) Read(p []byte) (int, error) {
+	if !s.skipped {
+		if err := s.skip(); err != nil {
+			return 0, err
+		}
+	}
+	if len(s.pending) > 0 {
+		n := copy(p, s.pending)
+		s.pending = s.pending[n:]
+		return n, nil
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.src.Read(p)
+}
+
+// skip reads src until it finds the first boundary. If src ends first, it's
+// all one partial record, so nothing of it is returned.
+func (s *boundarySkipper) skip() error {
+	buf := make([]byte, s.r.ChunkSize)
+	keep := s.r.longestBoundary() - 1
+	var window []byte
+	var read int64
+
+	for {
+		n, err := s.src.Read(buf)
+		window = append(window, buf[:n]...)
+		read += int64(n)
+
+		if end := s.r.firstBoundaryEnd(window, err != nil); end > -1 {
+			s.skipped = true
+			s.pending, s.err = window[end:], err
+			if s.r.checkpoints != nil {
+				s.r.checkpoints.dropped(read - int64(len(s.pending)))
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Only the end of the window could be the start of a boundary that's
+		// completed by the next read.
+		if len(window) > keep {
+			window = append(window[:0], window[len(window)-keep:]...)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package rip
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipToFirstBoundary(t *testing.T) {
+	assert := assert.New(t)
+
+	read := func(r *ParallelReader, stream io.Reader) []string {
+		var mu sync.Mutex
+		var chunks []string
+		r.ReadSerial(stream, func(chunk []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			chunks = append(chunks, string(chunk))
+		})
+		return chunks
+	}
+
+	t.Run("with a stream that starts mid-record", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.SkipToFirstBoundary = true
+
+		assert.Equal([]string{"abc\n", "def\n"}, read(r, strings.NewReader("yz\nabc\ndef\n")))
+	})
+
+	t.Run("skips the first record even if it's complete", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.SkipToFirstBoundary = true
+
+		assert.Equal([]string{"def\n"}, read(r, strings.NewReader("abc\ndef\n")))
+	})
+
+	t.Run("when the partial record is longer than a read", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ChunkBoundary = "||"
+		r.SkipToFirstBoundary = true
+
+		assert.Equal([]string{"|abc||", "de||"}, read(r, iotest.OneByteReader(strings.NewReader("partial record|||abc||de||"))))
+	})
+
+	t.Run("with ChunkBoundaries", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ChunkBoundaries = []string{"\n", "\r\n"}
+		r.SkipToFirstBoundary = true
+
+		assert.Equal([]string{"abc\n", "def\r\n"}, read(r, strings.NewReader("yz\r\nabc\ndef\r\n")))
+	})
+
+	t.Run("when there's no boundary", func(t *testing.T) {
+		r := NewParallelReader()
+		r.SkipToFirstBoundary = true
+
+		assert.Empty(read(r, strings.NewReader("partial record")))
+	})
+
+	t.Run("with a file that would be scanned in parallel", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.ParallelScan = true
+		r.SkipToFirstBoundary = true
+
+		path := filepath.Join(t.TempDir(), "tail.log")
+		assert.NoError(os.WriteFile(path, []byte("yz\nabc\ndef\n"), 0o644))
+
+		var mu sync.Mutex
+		var chunks []string
+		assert.NoError(r.ReadFile(path, func(chunk []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			chunks = append(chunks, string(chunk))
+		}))
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, chunks)
+	})
+}
@@ -0,0 +1,131 @@
+package rip
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Result describes how a read finished.
+type Result struct {
+	// True if the stream was read all the way to EOF, or false if the read was
+	// stopped early.
+	Completed bool
+	Stats     Stats
+
+	// The error that ended the read, if RecoverPanics is set. Without it, errors
+	// are panics instead.
+	Err error
+}
+
+// Stats describes the most recent read by a ParallelReader.
+type Stats struct {
+	// The number of chunks, and the total number of bytes in them, that were
+	// dispatched to workers.
+	Chunks int64
+	Bytes  int64
+
+	// The number of records in the stream, counted only when ExpectedRecords is
+	// set.
+	Records int64
+
+	// The number of bytes at the end of the stream that were dropped because
+	// RequireBoundary was set and they weren't terminated by a ChunkBoundary.
+	DroppedBytes int64
+
+	// The total time the scanner spent waiting to hand chunks to busy workers,
+	// and the total time, summed across all workers, that workers spent waiting
+	// for the scanner to produce chunks. A high ScannerBlockedNanos means the
+	// workers are the bottleneck; a high WorkerIdleNanos means the scanner is.
+	// Only recorded when Profile is set.
+	ScannerBlockedNanos int64
+	WorkerIdleNanos     int64
+
+	// The number of dispatched chunks in each size range, split at
+	// SizeHistogramBounds: SizeHistogram[i] counts chunks smaller than
+	// SizeHistogramBounds[i] that didn't fit an earlier bucket, and the last
+	// bucket counts everything from the last bound up. Lots of chunks well
+	// under ChunkSize suggest records are being split poorly.
+	SizeHistogram [len(SizeHistogramBounds) + 1]int64
+
+	// The sum of WholeHash over everything read from the stream, if it's set.
+	WholeHash []byte
+}
+
+// SizeHistogramBounds are the upper bounds, in bytes, of the buckets in
+// Stats.SizeHistogram: under 1 KiB, 1-4 KiB, 4-16 KiB, 16-64 KiB, and 64 KiB or
+// more.
+var SizeHistogramBounds = [...]int{1 << 10, 4 << 10, 16 << 10, 64 << 10}
+
+// Scan reads stream like Read, but only to gather the Stats it returns, as a
+// quick way to find how many chunks and bytes a stream makes for with the
+// reader's settings. No callback is called, so chunks aren't copied out of the
+// scanner's buffer or handed to workers. Errors reading stream are returned
+// rather than panicking.
+func (r *ParallelReader) Scan(stream io.Reader) (Stats, error) {
+	r.discard = true
+	defer func() { r.discard = false }()
+
+	err := r.dispatch(r.producer(stream), stream, nil, func(c *chunk) {})
+	return r.Stats(), err
+}
+
+// Stats returns statistics about the most recent read. It should only be
+// called once the read has returned.
+func (r *ParallelReader) Stats() Stats {
+	return *r.stats
+}
+
+// Processed returns the number of chunks, and the total number of bytes in
+// them, that workers have finished with so far in the current or most recent
+// read. Unlike Stats, it's safe to call from another goroutine while a read is
+// running, so a progress display can poll it at its own pace. A chunk counts
+// once its callback has returned, so with Transform, its output may not have
+// been written yet.
+func (r *ParallelReader) Processed() (chunks int64, bytes int64) {
+	return atomic.LoadInt64(&r.processed.chunks), atomic.LoadInt64(&r.processed.bytes)
+}
+
+// progress counts the chunks that have been processed, for Processed, and in
+// total over every read, for Metrics.
+type progress struct {
+	chunks int64
+	bytes  int64
+
+	totalChunks int64
+	totalBytes  int64
+}
+
+func (p *progress) add(c *chunk) {
+	atomic.AddInt64(&p.chunks, 1)
+	atomic.AddInt64(&p.bytes, int64(c.readableSize))
+	atomic.AddInt64(&p.totalChunks, 1)
+	atomic.AddInt64(&p.totalBytes, int64(c.readableSize))
+}
+
+func (p *progress) reset() {
+	atomic.StoreInt64(&p.chunks, 0)
+	atomic.StoreInt64(&p.bytes, 0)
+}
+
+func (r *ParallelReader) result() Result {
+	return Result{Completed: r.completed, Stats: *r.stats}
+}
+
+// countChunk records that c was dispatched. It's safe to call concurrently, as
+// it is when ParallelScan is set.
+func (r *ParallelReader) countChunk(c *chunk) {
+	atomic.AddInt64(&r.stats.Chunks, 1)
+	atomic.AddInt64(&r.stats.Bytes, int64(c.readableSize))
+	atomic.AddInt64(&r.stats.SizeHistogram[sizeBucket(c.readableSize)], 1)
+}
+
+// sizeBucket returns the index in Stats.SizeHistogram for a chunk of size
+// bytes.
+func sizeBucket(size int) int {
+	for i, bound := range SizeHistogramBounds {
+		if size < bound {
+			return i
+		}
+	}
+	return len(SizeHistogramBounds)
+}
@@ -0,0 +1,209 @@
+package rip
+
+import (
+	"crypto/sha256"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResult(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("when the whole stream is read", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		result := r.Read(strings.NewReader("abc\ndef\n"), func(chunk []byte) {})
+
+		assert.True(result.Completed)
+		assert.EqualValues(2, result.Stats.Chunks)
+	})
+
+	t.Run("when the read is stopped early", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		done := make(chan struct{})
+		close(done)
+		r.run(strings.NewReader("abc\ndef\n"), done, func(c *chunk) {})
+
+		assert.False(r.result().Completed)
+	})
+}
+
+func TestStats(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("counts chunks and bytes", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		r.Read(strings.NewReader("abc\ndef\nghi"), func(chunk []byte) {})
+
+		stats := r.Stats()
+		assert.EqualValues(3, stats.Chunks)
+		assert.EqualValues(11, stats.Bytes)
+		assert.Zero(stats.ScannerBlockedNanos)
+		assert.Zero(stats.WorkerIdleNanos)
+	})
+
+	t.Run("counts bytes dropped by RequireBoundary", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkBoundary = "|SPLIT|"
+		r.RequireBoundary = true
+
+		var dropped string
+		r.OnDropped = func(data []byte) {
+			dropped = string(data)
+		}
+
+		r.Read(strings.NewReader("abcdefg|SPLIT|hello"), func(chunk []byte) {})
+
+		assert.EqualValues(5, r.Stats().DroppedBytes)
+		assert.Equal("hello", dropped)
+	})
+
+	t.Run("with Profile and slow workers", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 1
+		r.ChunkSize = 4
+		r.Profile = true
+
+		r.Read(strings.NewReader(strings.Repeat("abc\n", 10)), func(chunk []byte) {
+			time.Sleep(time.Millisecond)
+		})
+
+		stats := r.Stats()
+		assert.EqualValues(10, stats.Chunks)
+		assert.Greater(stats.ScannerBlockedNanos, int64(time.Millisecond))
+	})
+
+	t.Run("buckets chunks by size", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 5000
+
+		// Each record is too large to share a chunk with the one after it.
+		input := strings.Repeat("a", 2999) + "\n" + strings.Repeat("b", 4999) + "\n" + strings.Repeat("c", 99) + "\n"
+		r.Read(strings.NewReader(input), func(chunk []byte) {})
+
+		assert.Equal([5]int64{1, 1, 1, 0, 0}, r.Stats().SizeHistogram)
+	})
+
+	t.Run("with WholeHash", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.RequireBoundary = true
+		r.WholeHash = sha256.New()
+
+		input := "abc\ndef\nunterminated"
+		r.Read(strings.NewReader(input), func(chunk []byte) {})
+
+		// Dropped bytes were still read, so they're part of the hash.
+		expected := sha256.Sum256([]byte(input))
+		assert.Equal(expected[:], r.Stats().WholeHash)
+
+		// The hash is reset for each read.
+		r.Read(strings.NewReader(input), func(chunk []byte) {})
+		assert.Equal(expected[:], r.Stats().WholeHash)
+	})
+}
+
+func TestScan(t *testing.T) {
+	assert := assert.New(t)
+
+	input := strings.Repeat("abc\n", 100) + "de"
+
+	t.Run("gathers the same stats as Read", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+
+		r.Read(strings.NewReader(input), func(chunk []byte) {})
+		expected := r.Stats()
+
+		var borrowed int64
+		r.Pool().OnBorrow = func([]byte) { atomic.AddInt64(&borrowed, 1) }
+
+		stats, err := r.Scan(strings.NewReader(input))
+		assert.NoError(err)
+		assert.Equal(expected, stats)
+		assert.Zero(borrowed)
+	})
+
+	t.Run("with CoalesceFinal", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+		r.CoalesceFinal = true
+		r.MinChunkSize = 4
+
+		r.Read(strings.NewReader(input), func(chunk []byte) {})
+		expected := r.Stats()
+
+		// Nothing from the scanner's buffer should be returned to the pool.
+		var returned int64
+		r.Pool().OnReturn = func([]byte) { atomic.AddInt64(&returned, 1) }
+
+		stats, err := r.Scan(strings.NewReader(input))
+		assert.NoError(err)
+		assert.Equal(expected, stats)
+		assert.Zero(returned)
+	})
+
+	t.Run("with a stream error", func(t *testing.T) {
+		r := NewParallelReader()
+
+		_, err := r.Scan(io.MultiReader(strings.NewReader(input), iotest.ErrReader(errTest)))
+		assert.ErrorIs(err, errTest)
+	})
+}
+
+func TestProcessed(t *testing.T) {
+	assert := assert.New(t)
+
+	input := strings.Repeat("abc\n", 100)
+
+	t.Run("can be polled while a read runs", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 1
+		r.ChunkSize = 4
+
+		release := make(chan struct{})
+		finished := make(chan Result)
+		go func() {
+			finished <- r.Read(strings.NewReader(input), func(chunk []byte) {
+				<-release
+			})
+		}()
+
+		// The only worker is held up on the first chunk, so nothing has finished.
+		time.Sleep(10 * time.Millisecond)
+		chunks, bytes := r.Processed()
+		assert.Zero(chunks)
+		assert.Zero(bytes)
+
+		close(release)
+		result := <-finished
+
+		chunks, bytes = r.Processed()
+		assert.EqualValues(100, chunks)
+		assert.Equal(result.Stats.Chunks, chunks)
+		assert.Equal(result.Stats.Bytes, bytes)
+	})
+
+	t.Run("with ParallelScan", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+		r.ParallelScan = true
+
+		result := r.Read(strings.NewReader(input), func(chunk []byte) {})
+
+		chunks, bytes := r.Processed()
+		assert.Equal(result.Stats.Chunks, chunks)
+		assert.EqualValues(len(input), bytes)
+	})
+}
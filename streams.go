@@ -0,0 +1,21 @@
+package rip
+
+import (
+	"bytes"
+	"io"
+)
+
+// ReadStreams is like Read, but passes each chunk to the callback as an
+// io.Reader rather than a byte slice, for consumers that want to stream a
+// chunk somewhere (such as one part of a multipart upload) rather than handle
+// it as a whole. Chunks are still buffered in full before the callback is
+// called; the io.Reader only leaves room for chunks that aren't.
+//
+// Like the slices passed to Read's callback, the io.Reader is only valid until
+// the callback returns, and mustn't be read from after that.
+func (r *ParallelReader) ReadStreams(stream io.Reader, work func(chunk io.Reader)) Result {
+	err := r.read(stream, func(chunk []byte) {
+		work(bytes.NewReader(chunk))
+	})
+	return r.finish(err)
+}
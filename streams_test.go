@@ -0,0 +1,27 @@
+package rip
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadStreams(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewParallelReader()
+	r.ChunkSize = 4
+
+	chunks := make(chan string, 128)
+	result := r.ReadStreams(strings.NewReader("abc\ndef\n"), func(chunk io.Reader) {
+		data, err := io.ReadAll(chunk)
+		assert.NoError(err)
+		chunks <- string(data)
+	})
+	close(chunks)
+
+	assert.True(result.Completed)
+	assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+}
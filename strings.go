@@ -0,0 +1,23 @@
+package rip
+
+import (
+	"io"
+	"unsafe"
+)
+
+// ReadStrings is like Read, but passes each chunk to work as a string, for
+// callbacks that treat records as text, without the allocation and copy that
+// string(chunk) would make for every chunk.
+//
+// The string shares its memory with the chunk's buffer, which is reused for
+// later chunks once work returns, so the same rule applies to it as to the
+// chunks passed by Read, only more strictly: it must not be used, or kept in
+// any way, after work returns, including as a map key or in a substring, since
+// strings are otherwise assumed never to change. Use strings.Clone to keep any
+// part of it. NormalizeLineEndings, which rewrites chunks in place, is applied
+// before work is called, so the string doesn't change while work runs.
+func (r *ParallelReader) ReadStrings(stream io.Reader, work func(chunk string)) Result {
+	return r.Read(stream, func(chunk []byte) {
+		work(unsafe.String(unsafe.SliceData(chunk), len(chunk)))
+	})
+}
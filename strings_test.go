@@ -0,0 +1,40 @@
+package rip
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadStrings(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("passes every chunk as a string", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		chunks := make(chan string, 128)
+		result := r.ReadStrings(strings.NewReader("abc\ndef\nghi\n"), func(chunk string) {
+			chunks <- strings.Clone(chunk)
+		})
+		close(chunks)
+
+		assert.True(result.Completed)
+		assert.ElementsMatch([]string{"abc\n", "def\n", "ghi\n"}, drain(chunks))
+	})
+
+	t.Run("with NormalizeLineEndings", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 5
+		r.NormalizeLineEndings = true
+
+		chunks := make(chan string, 128)
+		r.ReadStrings(strings.NewReader("abc\r\ndef\r\n"), func(chunk string) {
+			chunks <- strings.Clone(chunk)
+		})
+		close(chunks)
+
+		assert.ElementsMatch([]string{"abc", "def"}, drain(chunks))
+	})
+}
@@ -0,0 +1,138 @@
+package rip
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// A tar archive entry being streamed to a worker.
+type tarEntry struct {
+	header *tar.Header
+	pieces chan *chunk
+}
+
+// ReadTar reads the tar archive in stream, calling work for the content of
+// each entry from a pool of Concurrency goroutines, so entries are processed
+// in parallel even though the archive itself has to be read serially.
+//
+// To keep memory bounded however large the entries are, content is read into
+// pooled buffers of ChunkSize: an entry that doesn't fit in one is passed to
+// work in several consecutive calls with the same header, all on the same
+// goroutine and in order, while the archive is read further. Entries with no
+// content, like directories, get a single call with empty content. As with
+// Read, content must not be used after work returns.
+//
+// The run stops at the first error returned by work or from reading the
+// archive, which is returned; work can return Stop to end the run early
+// without an error.
+func (r *ParallelReader) ReadTar(stream io.Reader, work func(header *tar.Header, content []byte) error) error {
+	r.preparePool()
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	entries := make(chan *tarEntry, r.Concurrency)
+
+	var wg sync.WaitGroup
+	wg.Add(r.Concurrency)
+	for i := 0; i < r.Concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for entry := range entries {
+				for c := range entry.pieces {
+					if ctx.Err() == nil {
+						if err := work(entry.header, c.ReadableBytes()); err != nil {
+							cancel(err)
+						}
+					}
+					r.pool.Return(c.buffer)
+				}
+			}
+		}()
+	}
+
+	readErr := r.readTar(tar.NewReader(r.retrying(stream)), entries, ctx.Done())
+	close(entries)
+	wg.Wait()
+
+	if err := context.Cause(ctx); err != nil {
+		if errors.Is(err, Stop) {
+			return nil
+		}
+		return err
+	}
+	return readErr
+}
+
+// readTar sends each entry of tr to entries, then streams its content to the
+// entry in pieces of up to ChunkSize, until tr is exhausted or done is closed.
+func (r *ParallelReader) readTar(tr *tar.Reader, entries chan<- *tarEntry, done <-chan struct{}) error {
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		entry := &tarEntry{header: header, pieces: make(chan *chunk, 1)}
+		select {
+		case entries <- entry:
+		case <-done:
+			return nil
+		}
+
+		err = r.readTarEntry(tr, entry.pieces, done)
+		close(entry.pieces)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+	}
+}
+
+// readTarEntry sends the content of the current entry of tr to pieces.
+func (r *ParallelReader) readTarEntry(tr *tar.Reader, pieces chan<- *chunk, done <-chan struct{}) error {
+	for first := true; ; first = false {
+		// Fill buf by hand rather than with io.ReadFull, which would disguise a
+		// truncated archive as the end of the entry.
+		buf := r.pool.Borrow()
+		var n int
+		var err error
+		for n < len(buf) && err == nil {
+			var m int
+			m, err = tr.Read(buf[n:])
+			n += m
+		}
+		if err != nil && err != io.EOF {
+			r.pool.Return(buf)
+			return err
+		}
+
+		// Send empty content only when the entry has none at all.
+		if n == 0 && !first {
+			r.pool.Return(buf)
+			return nil
+		}
+
+		select {
+		case pieces <- &chunk{buffer: buf, readableSize: n}:
+		case <-done:
+			r.pool.Return(buf)
+			return nil
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
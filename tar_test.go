@@ -0,0 +1,71 @@
+package rip
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadTar(t *testing.T) {
+	assert := assert.New(t)
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755})
+	for i, content := range []string{"small", strings.Repeat("large", 10), ""} {
+		tw.WriteHeader(&tar.Header{Name: fmt.Sprintf("file%d", i), Size: int64(len(content)), Mode: 0644})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+
+	t.Run("passes the content of every entry", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+
+		var mu sync.Mutex
+		contents := map[string]string{}
+		err := r.ReadTar(bytes.NewReader(archive.Bytes()), func(header *tar.Header, content []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			contents[header.Name] += string(content)
+			return nil
+		})
+
+		assert.NoError(err)
+		assert.Equal(map[string]string{
+			"dir/":  "",
+			"file0": "small",
+			"file1": strings.Repeat("large", 10),
+			"file2": "",
+		}, contents)
+	})
+
+	t.Run("returns callback errors", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+
+		errBoom := errors.New("boom")
+		err := r.ReadTar(bytes.NewReader(archive.Bytes()), func(header *tar.Header, content []byte) error {
+			return errBoom
+		})
+
+		assert.ErrorIs(err, errBoom)
+	})
+
+	t.Run("when the archive is truncated", func(t *testing.T) {
+		r := NewParallelReader()
+
+		err := r.ReadTar(bytes.NewReader(archive.Bytes()[:2060]), func(header *tar.Header, content []byte) error {
+			return nil
+		})
+
+		assert.ErrorIs(err, io.ErrUnexpectedEOF)
+	})
+}
@@ -0,0 +1,327 @@
+package rip
+
+import (
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// The output produced by a worker for the chunk at index, which was size bytes
+// long and began at offset in the input.
+type chunkResult struct {
+	index  int
+	offset int64
+	size   int
+	output []byte
+	// Whether Middleware skipped the chunk, so output is empty.
+	skipped bool
+}
+
+// Transform reads in from a pool of goroutines like Read, calling fn once per
+// chunk, and writes each chunk's output to out in the same order the chunks
+// appeared in the input. If OutputSeparator is set, it's written between
+// successive non-empty outputs.
+//
+// This makes a complete streaming filter, like a parallel sed: in is read on
+// the calling goroutine, fn runs on Concurrency workers, and a single writer
+// goroutine writes to out, holding outputs that finish early in a reorder
+// window of MaxReorderBuffer chunks. An error from any stage stops the others
+// and is returned: a read error, a write error, or, with RecoverPanics, a
+// *PanicError for a panic in fn. Since reading and writing each take a single
+// goroutine, Concurrency sets only the number of workers running fn, so there
+// is no separate transform concurrency to configure.
+func (r *ParallelReader) Transform(in io.Reader, out io.Writer, fn func(chunk []byte) []byte) error {
+	return r.ExpandTransform(in, out, func(chunk []byte, emit func([]byte)) {
+		emit(fn(chunk))
+	})
+}
+
+// ExpandTransform is like Transform, but fn may produce any number of outputs
+// per chunk by calling emit zero or more times. Outputs are written to out in
+// input chunk order, and the outputs of a single chunk are written in the order
+// they were emitted.
+//
+// Emitted bytes are copied, so it's safe to emit slices of chunk.
+func (r *ParallelReader) ExpandTransform(in io.Reader, out io.Writer, fn func(chunk []byte, emit func([]byte))) error {
+	return r.expandTransform(r.run, in, out, r.OutputSeparator, fn)
+}
+
+// TransformFixed is like Transform, but splits in into blocks of ChunkSize like
+// ReadFixed, and writes the outputs back to back, without OutputSeparator, so
+// that the output is the blocks' outputs concatenated in input order. This
+// suits block-wise encryption or compression. The output of fn is copied, so
+// fn may transform block in place and return it.
+func (r *ParallelReader) TransformFixed(in io.Reader, out io.Writer, fn func(block []byte) []byte) error {
+	return r.expandTransform(r.runFixed, in, out, nil, func(block []byte, emit func([]byte)) {
+		emit(fn(block))
+	})
+}
+
+// expandTransform is ExpandTransform, but splits in with run and writes
+// separator between outputs.
+func (r *ParallelReader) expandTransform(run func(io.Reader, <-chan struct{}, func(*chunk)) error, in io.Reader, out io.Writer, separator []byte, fn func(chunk []byte, emit func([]byte))) error {
+	results := make(chan *chunkResult, r.Concurrency)
+	done := make(chan struct{})
+	w := &orderedWriter{out: out, separator: separator, window: r.newReorderWindow(), flushInterval: r.FlushInterval}
+
+	writeErr := make(chan error, 1)
+	go func() {
+		writeErr <- w.writeAll(results, done)
+	}()
+
+	defer r.inOrder(func(c *chunk) {
+		w.window.wait(c.index)
+		results <- &chunkResult{index: c.index, skipped: true}
+	})()
+
+	scanErr := run(in, done, func(c *chunk) {
+		sent := false
+		defer w.window.abandon(&sent)
+
+		var output []byte
+		fn(c.ReadableBytes(), func(b []byte) {
+			if len(b) == 0 {
+				return
+			}
+			if len(output) > 0 {
+				output = append(output, separator...)
+			}
+			output = append(output, b...)
+		})
+		w.window.wait(c.index)
+		results <- &chunkResult{index: c.index, output: output}
+		sent = true
+	})
+	close(results)
+
+	if err := <-writeErr; err != nil {
+		return err
+	}
+	return scanErr
+}
+
+// orderedWriter writes the output of results to out sequentially by index,
+// holding on to results that arrive ahead of their turn.
+type orderedWriter struct {
+	out io.Writer
+	// Written between the outputs of successive results. Empty outputs are
+	// skipped entirely, so they don't produce doubled separators.
+	separator []byte
+	// Advanced as results are written.
+	window *reorderWindow
+	// If not nil, called after each result is written.
+	written func(res *chunkResult)
+	// How often to flush out, if it can be flushed (see FlushInterval).
+	flushInterval time.Duration
+
+	wroteAny  bool
+	unflushed bool
+}
+
+// flusher is implemented by writers that buffer, like bufio.Writer and
+// gzip.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// httpFlusher is implemented by writers that buffer, but can't fail to flush,
+// like http.ResponseWriter.
+type httpFlusher interface {
+	Flush()
+}
+
+// writeAll writes results until the channel is closed. If a write fails, done
+// is closed to stop the scan, and the remaining results are drained without
+// being written so that workers don't block.
+//
+// If flushInterval is set and out can be flushed, out is flushed at each
+// interval in which something was written, and once more at the end.
+func (w *orderedWriter) writeAll(results <-chan *chunkResult, done chan<- struct{}) error {
+	var err error
+	pending := make(map[int]*chunkResult)
+	next := 0
+
+	fail := func(e error) {
+		err = e
+		close(done)
+		w.window.release()
+	}
+
+	var tick <-chan time.Time
+	if w.flushInterval > 0 && w.canFlush() {
+		ticker := time.NewTicker(w.flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		var res *chunkResult
+		select {
+		case res = <-results:
+		case <-tick:
+			if e := w.flush(); err == nil && e != nil {
+				fail(e)
+			}
+			continue
+		}
+		if res == nil {
+			break
+		}
+		pending[res.index] = res
+
+		for err == nil {
+			res, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			if e := w.write(res.output); e != nil {
+				fail(e)
+				break
+			}
+			if w.written != nil {
+				w.written(res)
+			}
+			w.window.advance(next)
+		}
+	}
+
+	if tick != nil && err == nil {
+		err = w.flush()
+	}
+	return err
+}
+
+// canFlush reports whether out buffers what's written to it, and so can be
+// flushed.
+func (w *orderedWriter) canFlush() bool {
+	switch w.out.(type) {
+	case flusher, httpFlusher:
+		return true
+	}
+	return false
+}
+
+// flush flushes out, if anything has been written since it was last flushed.
+func (w *orderedWriter) flush() error {
+	if !w.unflushed {
+		return nil
+	}
+	w.unflushed = false
+
+	switch out := w.out.(type) {
+	case flusher:
+		return out.Flush()
+	case httpFlusher:
+		out.Flush()
+	}
+	return nil
+}
+
+func (w *orderedWriter) write(output []byte) error {
+	if len(output) == 0 {
+		return nil
+	}
+
+	if w.wroteAny && len(w.separator) > 0 {
+		if _, err := w.out.Write(w.separator); err != nil {
+			return err
+		}
+	}
+	w.wroteAny = true
+	w.unflushed = true
+
+	_, err := w.out.Write(output)
+	return err
+}
+
+// reorderWindow bounds how far ahead of the next result to be written a worker
+// may deliver its own result, so that a single slow chunk can't cause an
+// unbounded number of completed results to pile up waiting for it.
+type reorderWindow struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	next int
+	size int
+}
+
+// inOrder sets r up for a run of one of the ordered APIs, until the returned
+// function is called: skipped is called with each chunk that Middleware skips,
+// and Priority is ignored, since the chunk the reorder window is waiting for
+// could otherwise be held back behind later ones until every worker is stuck
+// waiting for it.
+func (r *ParallelReader) inOrder(skipped func(c *chunk)) func() {
+	r.skipped, r.ordered = skipped, true
+	return func() { r.skipped, r.ordered = nil, false }
+}
+
+// newReorderWindow returns a reorderWindow of MaxReorderBuffer results.
+func (r *ParallelReader) newReorderWindow() *reorderWindow {
+	size := r.MaxReorderBuffer
+	if size <= 0 {
+		size = 4 * r.Concurrency
+	}
+
+	w := &reorderWindow{size: size}
+	w.cond = sync.NewCond(&w.mu)
+	return w
+}
+
+// wait blocks until the result at index fits within the window.
+func (w *reorderWindow) wait(index int) {
+	w.mu.Lock()
+	// Compared this way round, next+size can't overflow once size is released to
+	// math.MaxInt.
+	for index-w.next >= w.size {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+}
+
+// advance moves the start of the window to next, unblocking any workers whose
+// results now fit.
+func (w *reorderWindow) advance(next int) {
+	w.mu.Lock()
+	w.next = next
+	w.mu.Unlock()
+	w.cond.Broadcast()
+}
+
+// abandon is deferred by a worker that's to deliver a result, and releases the
+// window if sent is still false, because the callback producing the result
+// panicked and RecoverPanics recovered it. Nothing after the missing result
+// can be written, so workers with later results mustn't wait for it forever.
+func (w *reorderWindow) abandon(sent *bool) {
+	if !*sent {
+		w.release()
+	}
+}
+
+// release unblocks all current and future waiters, once results are no longer
+// being written.
+func (w *reorderWindow) release() {
+	w.mu.Lock()
+	w.size = math.MaxInt
+	w.mu.Unlock()
+	w.cond.Broadcast()
+}
+
+// TransformReader is like Transform, but rather than writing the ordered
+// output to a writer, it returns a reader that yields it, for chaining with
+// other stages of a pipeline (with io.Copy, for example). The transform runs in
+// the background while the reader is read from; when the reader isn't keeping
+// up, the workers stop and wait for it. Errors reading in are returned by the
+// reader once the output before them has been read.
+//
+// Callers that stop reading before EOF should Close the reader so that the
+// background transform stops too.
+func (r *ParallelReader) TransformReader(in io.Reader, fn func(chunk []byte) []byte) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(r.Transform(in, pw, fn))
+	}()
+	return pr
+}
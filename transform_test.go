@@ -0,0 +1,327 @@
+package rip
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("writes output in input order", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var input strings.Builder
+		for i := 0; i < 1000; i++ {
+			input.WriteString("abc\n")
+		}
+
+		var out bytes.Buffer
+		err := r.Transform(strings.NewReader(input.String()), &out, func(chunk []byte) []byte {
+			return bytes.ToUpper(chunk)
+		})
+
+		assert.NoError(err)
+		assert.Equal(strings.ToUpper(input.String()), out.String())
+	})
+
+	t.Run("with MaxReorderBuffer and a slow chunk", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 4
+		r.ChunkSize = 6
+		r.MaxReorderBuffer = 2
+
+		input := "first\n" + strings.Repeat("abcde\n", 1000)
+
+		var completed, completedWhileSlow int64
+		var out bytes.Buffer
+		err := r.Transform(strings.NewReader(input), &out, func(chunk []byte) []byte {
+			if string(chunk) == "first\n" {
+				time.Sleep(50 * time.Millisecond)
+				completedWhileSlow = atomic.LoadInt64(&completed)
+			}
+			atomic.AddInt64(&completed, 1)
+			return chunk
+		})
+
+		assert.NoError(err)
+		assert.Equal(input, out.String())
+
+		// While the slow chunk was in progress, each of the other workers could
+		// complete one chunk it then had to wait to deliver, plus the one chunk
+		// that fit in the window behind the slow one.
+		assert.LessOrEqual(completedWhileSlow, int64(r.Concurrency))
+	})
+
+	t.Run("with OutputSeparator", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.OutputSeparator = []byte("\n")
+
+		var out bytes.Buffer
+		err := r.Transform(strings.NewReader("abc\nxyz\ndef\nxyz\n"), &out, func(chunk []byte) []byte {
+			if bytes.HasPrefix(chunk, []byte("x")) {
+				return nil
+			}
+			return bytes.TrimSuffix(chunk, []byte("\n"))
+		})
+
+		assert.NoError(err)
+		assert.Equal("abc\ndef", out.String())
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		err := r.Transform(strings.NewReader("abc\ndef\n"), failingWriter{}, func(chunk []byte) []byte {
+			return chunk
+		})
+
+		assert.ErrorIs(err, errWrite)
+	})
+
+	t.Run("returns read errors", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var out bytes.Buffer
+		err := r.Transform(iotest.TimeoutReader(strings.NewReader(strings.Repeat("abc\n", 1000))), &out, func(chunk []byte) []byte {
+			return chunk
+		})
+
+		assert.ErrorIs(err, iotest.ErrTimeout)
+	})
+
+	t.Run("with RecoverPanics and a panicking callback", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.RecoverPanics = true
+
+		var chunks int64
+		var out bytes.Buffer
+		err := r.Transform(strings.NewReader(strings.Repeat("abc\n", 1000)), &out, func(chunk []byte) []byte {
+			if atomic.AddInt64(&chunks, 1) == 50 {
+				panic("boom")
+			}
+			return chunk
+		})
+
+		var panicErr *PanicError
+		if assert.ErrorAs(err, &panicErr) {
+			assert.Equal("boom", panicErr.Value)
+		}
+	})
+}
+
+func TestExpandTransform(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("writes each emit in order", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+
+		var out bytes.Buffer
+		err := r.ExpandTransform(strings.NewReader("a,b\nc,d\ne\n"), &out, func(chunk []byte, emit func([]byte)) {
+			for _, field := range bytes.Split(bytes.TrimSuffix(chunk, []byte("\n")), []byte(",")) {
+				emit(field)
+				emit([]byte("\n"))
+			}
+		})
+
+		assert.NoError(err)
+		assert.Equal("a\nb\nc\nd\ne\n", out.String())
+	})
+
+	t.Run("with OutputSeparator", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 8
+		r.OutputSeparator = []byte(";")
+
+		var out bytes.Buffer
+		err := r.ExpandTransform(strings.NewReader("a,b\nc,,d\n"), &out, func(chunk []byte, emit func([]byte)) {
+			for _, field := range bytes.Split(bytes.TrimSuffix(chunk, []byte("\n")), []byte(",")) {
+				emit(field)
+			}
+		})
+
+		assert.NoError(err)
+		assert.Equal("a;b;c;d", out.String())
+	})
+
+	t.Run("when a chunk emits nothing", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var out bytes.Buffer
+		err := r.ExpandTransform(strings.NewReader("abc\nxyz\ndef\n"), &out, func(chunk []byte, emit func([]byte)) {
+			if !bytes.HasPrefix(chunk, []byte("x")) {
+				emit(chunk)
+			}
+		})
+
+		assert.NoError(err)
+		assert.Equal("abc\ndef\n", out.String())
+	})
+}
+
+func TestFlushInterval(t *testing.T) {
+	assert := assert.New(t)
+
+	// A bufio.Writer holds on to output until it's flushed, so the output of the
+	// chunks before a slow one only reaches out if it's flushed in the meantime.
+	transform := func(r *ParallelReader, out *lockedBuffer) (flushedEarly bool) {
+		bw := bufio.NewWriterSize(out, 1<<20)
+		slow := make(chan struct{})
+
+		finished := make(chan error)
+		go func() {
+			finished <- r.Transform(strings.NewReader("abc\ndef\nslow\n"), bw, func(chunk []byte) []byte {
+				if string(chunk) == "slow\n" {
+					<-slow
+				}
+				return chunk
+			})
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		flushedEarly = out.Len() > 0
+		close(slow)
+		assert.NoError(<-finished)
+		return flushedEarly
+	}
+
+	t.Run("flushes the ready output while waiting for a slow chunk", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+		r.FlushInterval = time.Millisecond
+
+		var out lockedBuffer
+		assert.True(transform(r, &out))
+		assert.Equal("abc\ndef\nslow\n", out.String())
+	})
+
+	t.Run("without FlushInterval", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var out lockedBuffer
+		assert.False(transform(r, &out))
+	})
+}
+
+// lockedBuffer is a bytes.Buffer that's safe to check while it's written to.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Len()
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestTransformFixed(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("writes blocks back to back in input order", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+		r.OutputSeparator = []byte("|")
+
+		input := strings.Repeat("abcdefghij", 1000) + "xyz"
+
+		var out bytes.Buffer
+		err := r.TransformFixed(strings.NewReader(input), &out, func(block []byte) []byte {
+			// Transform the block in place, as a block cipher might.
+			for i, b := range block {
+				block[i] = b - 'a' + 'A'
+			}
+			return block
+		})
+
+		assert.NoError(err)
+		assert.Equal(strings.ToUpper(input), out.String())
+	})
+
+	t.Run("with a failing writer", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+
+		err := r.TransformFixed(strings.NewReader(strings.Repeat("a", 1000)), failingWriter{}, func(block []byte) []byte {
+			return block
+		})
+
+		assert.ErrorIs(err, errWrite)
+	})
+}
+
+func TestTransformReader(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("yields the output in input order", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		input := strings.Repeat("abc\n", 1000)
+		out, err := io.ReadAll(r.TransformReader(strings.NewReader(input), bytes.ToUpper))
+
+		assert.NoError(err)
+		assert.Equal(strings.ToUpper(input), string(out))
+	})
+
+	t.Run("when closed before EOF", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var chunks int64
+		out := r.TransformReader(strings.NewReader(strings.Repeat("abc\n", 10000)), func(chunk []byte) []byte {
+			atomic.AddInt64(&chunks, 1)
+			return chunk
+		})
+
+		buf := make([]byte, 4)
+		_, err := io.ReadFull(out, buf)
+		assert.NoError(err)
+		assert.NoError(out.Close())
+
+		// The transform should stop soon after the reader is closed.
+		time.Sleep(50 * time.Millisecond)
+		stopped := atomic.LoadInt64(&chunks)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(stopped, atomic.LoadInt64(&chunks))
+		assert.Less(stopped, int64(10000))
+	})
+}
+
+var errWrite = errors.New("write failed")
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errWrite
+}
@@ -0,0 +1,33 @@
+package rip
+
+import (
+	"fmt"
+	"io"
+)
+
+// TwoPass reads stream in full twice, calling first for each chunk of the
+// first pass and second for each chunk of the second, for jobs that need to
+// gather something about the whole input (a record count, the longest record)
+// before they can process it. Between passes, stream is seeked back to where
+// the first pass started.
+//
+// The second pass doesn't start until every call to first has returned, so
+// anything first collects is complete by then. An error is returned if stream
+// can't seek, which is checked before the first pass begins, or if either pass
+// fails to read.
+func (r *ParallelReader) TwoPass(stream io.ReadSeeker, first, second func(chunk []byte)) error {
+	start, err := stream.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("rip: TwoPass requires a seekable stream: %w", err)
+	}
+
+	if err := r.read(stream, first); err != nil {
+		return err
+	}
+
+	if _, err := stream.Seek(start, io.SeekStart); err != nil {
+		return fmt.Errorf("rip: TwoPass requires a seekable stream: %w", err)
+	}
+
+	return r.read(stream, second)
+}
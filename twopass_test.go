@@ -0,0 +1,48 @@
+package rip
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoPass(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("passes the whole stream to each callback", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 4
+
+		var records int64
+		chunks := make(chan string, 128)
+		err := r.TwoPass(strings.NewReader("abc\ndef\n"), func(chunk []byte) {
+			atomic.AddInt64(&records, 1)
+		}, func(chunk []byte) {
+			chunks <- string(chunk)
+			assert.EqualValues(2, atomic.LoadInt64(&records))
+		})
+		close(chunks)
+
+		assert.NoError(err)
+		assert.ElementsMatch([]string{"abc\n", "def\n"}, drain(chunks))
+	})
+
+	t.Run("when the stream can't seek", func(t *testing.T) {
+		pr, pw, err := os.Pipe()
+		if !assert.NoError(err) {
+			return
+		}
+		defer pr.Close()
+		pw.Close()
+
+		called := false
+		r := NewParallelReader()
+		err = r.TwoPass(pr, func(chunk []byte) { called = true }, func(chunk []byte) {})
+
+		assert.Error(err)
+		assert.False(called)
+	})
+}
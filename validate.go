@@ -0,0 +1,81 @@
+package rip
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ValidationError is returned by Validate at the first byte of a stream that
+// doesn't conform to the expected record framing.
+type ValidationError struct {
+	// The offset in the stream of the first nonconforming byte.
+	Offset int64
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("rip: invalid record at offset %d: %s", e.Offset, e.Reason)
+}
+
+// Validate checks that stream consists entirely of complete records, each
+// starting with ChunkBoundaryStart (if set) and ending with ChunkBoundary, with
+// nothing in between them. These are the records Read delivers when
+// RequireBoundary is set; but where Read silently drops anything that doesn't
+// fit, Validate returns a *ValidationError describing the first problem.
+//
+// Validate reads stream serially, one record at a time, so it's intended as a
+// lightweight lint for data files rather than something to run on every read.
+// Records longer than ChunkSize are reported as bufio.ErrTooLong. If
+// ChunkBoundaryStart is the same as ChunkBoundary, the marker that ends each
+// record also starts the next one, so the stream must end with a marker.
+func (r *ParallelReader) Validate(stream io.Reader) error {
+	start := []byte(r.ChunkBoundaryStart)
+	end := []byte(r.ChunkBoundary)
+	shared := r.sharedMarker()
+
+	if len(end) == 0 {
+		return errors.New("rip: Validate requires a ChunkBoundary")
+	}
+
+	var offset int64
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, r.ChunkSize), r.ChunkSize)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if shared && atEOF && r.equal(data, end) {
+			return len(data), nil, nil
+		}
+
+		// A record must begin with the start boundary, if there is one.
+		if !r.hasPrefix(data, start) {
+			if !atEOF && r.hasPrefix(start, data) {
+				return 0, nil, nil
+			}
+			return 0, nil, &ValidationError{Offset: offset, Reason: fmt.Sprintf("expected %q", start)}
+		}
+
+		idx := r.index(data[len(start):], end)
+		if idx < 0 {
+			if !atEOF {
+				return 0, nil, nil
+			}
+			return 0, nil, &ValidationError{Offset: offset, Reason: fmt.Sprintf("record not terminated by %q", end)}
+		}
+
+		advance := len(start) + idx + len(end)
+		if shared {
+			advance -= len(end)
+		}
+		offset += int64(advance)
+		return advance, data[:advance], nil
+	})
+
+	for scanner.Scan() {
+	}
+	return scanner.Err()
+}
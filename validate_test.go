@@ -0,0 +1,65 @@
+package rip
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	newReader := func() *ParallelReader {
+		r := NewParallelReader()
+		r.ChunkSize = 100
+		r.ChunkBoundaryStart = "<FOO>"
+		r.ChunkBoundary = "</FOO>"
+		r.RequireBoundary = true
+		return r
+	}
+
+	t.Run("with conforming input", func(t *testing.T) {
+		err := newReader().Validate(strings.NewReader("<FOO>abc</FOO><FOO>def</FOO>"))
+		assert.NoError(err)
+	})
+
+	t.Run("with junk between records", func(t *testing.T) {
+		err := newReader().Validate(strings.NewReader("<FOO>abc</FOO>junk<FOO>def</FOO>"))
+
+		var invalid *ValidationError
+		if assert.True(errors.As(err, &invalid)) {
+			assert.EqualValues(14, invalid.Offset)
+		}
+	})
+
+	t.Run("with an unterminated final record", func(t *testing.T) {
+		err := newReader().Validate(strings.NewReader("<FOO>abc</FOO><FOO>def"))
+
+		var invalid *ValidationError
+		if assert.True(errors.As(err, &invalid)) {
+			assert.EqualValues(14, invalid.Offset)
+		}
+	})
+
+	t.Run("without a ChunkBoundaryStart", func(t *testing.T) {
+		r := NewParallelReader()
+
+		assert.NoError(r.Validate(strings.NewReader("abc\ndef\n")))
+		assert.Error(r.Validate(strings.NewReader("abc\ndef")))
+	})
+
+	t.Run("when ChunkBoundaryStart and ChunkBoundary are the same marker", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkBoundaryStart = "#"
+		r.ChunkBoundary = "#"
+
+		assert.NoError(r.Validate(strings.NewReader("#rec1#rec2#rec3#")))
+
+		var invalid *ValidationError
+		if assert.True(errors.As(r.Validate(strings.NewReader("#rec1#rec2")), &invalid)) {
+			assert.EqualValues(5, invalid.Offset)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package rip
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ReadZip reads the central directory of the zip archive in source, which is
+// size bytes long, then decompresses its entries from a pool of Concurrency
+// goroutines, calling work for the content of each. Unlike a tar archive, a
+// zip archive's entries are compressed independently and can be found without
+// reading the ones before them, so they're decompressed in parallel too.
+//
+// As with ReadTar, content is read into pooled buffers of ChunkSize: an entry
+// that doesn't fit in one is passed to work in several consecutive calls with
+// the same name, all on the same goroutine and in order. Entries with no
+// content, like directories, get a single call with empty content, and content
+// must not be used after work returns.
+//
+// An entry that fails to decompress, or for which work returns an error,
+// doesn't stop the others from being processed; instead, all failures are
+// collected and returned together as a *MultiError, with one FileError per
+// failed entry in the order of the central directory. Work can return Stop to
+// end the run early, after which no more entries are started.
+func (r *ParallelReader) ReadZip(source io.ReaderAt, size int64, work func(name string, content []byte) error) error {
+	archive, err := zip.NewReader(source, size)
+	if err != nil {
+		return err
+	}
+
+	r.preparePool()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	indexes := make(chan int)
+	errs := make([]error, len(archive.File))
+
+	var wg sync.WaitGroup
+	wg.Add(r.Concurrency)
+	for i := 0; i < r.Concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				err := r.readZipEntry(archive.File[i], work)
+				if errors.Is(err, Stop) {
+					cancel()
+					continue
+				}
+				errs[i] = err
+			}
+		}()
+	}
+
+dispatch:
+	for i := range archive.File {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	var failed []*FileError
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, &FileError{Path: archive.File[i].Name, Err: err})
+		}
+	}
+	if len(failed) > 0 {
+		return &MultiError{Errors: failed}
+	}
+	return nil
+}
+
+// readZipEntry decompresses f and calls work for its content in pieces of up
+// to ChunkSize.
+func (r *ParallelReader) readZipEntry(f *zip.File, work func(name string, content []byte) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	buf := r.pool.Borrow()
+	defer r.pool.Return(buf)
+
+	for first := true; ; first = false {
+		// Fill buf by hand rather than with io.ReadFull, so that a checksum error
+		// reported along with the last bytes of the entry isn't lost.
+		var n int
+		var err error
+		for n < len(buf) && err == nil {
+			var m int
+			m, err = rc.Read(buf[n:])
+			n += m
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		// Send empty content only when the entry has none at all.
+		if n == 0 && !first {
+			return nil
+		}
+		if workErr := work(f.Name, buf[:n]); workErr != nil {
+			return workErr
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package rip
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadZip(t *testing.T) {
+	assert := assert.New(t)
+
+	newArchive := func(contents map[string]string) []byte {
+		var archive bytes.Buffer
+		zw := zip.NewWriter(&archive)
+		for _, name := range []string{"dir/", "file0", "file1", "file2", "file3"} {
+			if content, ok := contents[name]; ok {
+				w, _ := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+				w.Write([]byte(content))
+			}
+		}
+		zw.Close()
+		return archive.Bytes()
+	}
+
+	contents := map[string]string{
+		"dir/":  "",
+		"file0": "small",
+		"file1": strings.Repeat("large", 10),
+		"file2": "",
+	}
+	archive := newArchive(contents)
+
+	t.Run("passes the content of every entry", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+
+		var mu sync.Mutex
+		got := map[string]string{}
+		err := r.ReadZip(bytes.NewReader(archive), int64(len(archive)), func(name string, content []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got[name] += string(content)
+			return nil
+		})
+
+		assert.NoError(err)
+		assert.Equal(contents, got)
+	})
+
+	t.Run("decompresses entries in parallel", func(t *testing.T) {
+		r := NewParallelReader()
+		r.Concurrency = 4
+
+		var running, most int64
+		err := r.ReadZip(bytes.NewReader(archive), int64(len(archive)), func(name string, content []byte) error {
+			n := atomic.AddInt64(&running, 1)
+			defer atomic.AddInt64(&running, -1)
+			for {
+				m := atomic.LoadInt64(&most)
+				if n <= m || atomic.CompareAndSwapInt64(&most, m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		})
+
+		assert.NoError(err)
+		assert.Equal(int64(4), most)
+	})
+
+	t.Run("collects per-entry errors", func(t *testing.T) {
+		r := NewParallelReader()
+		r.ChunkSize = 16
+
+		errBoom := errors.New("boom")
+		var mu sync.Mutex
+		var processed []string
+		err := r.ReadZip(bytes.NewReader(archive), int64(len(archive)), func(name string, content []byte) error {
+			if strings.HasPrefix(name, "file") && name != "file2" {
+				return fmt.Errorf("%w in %s", errBoom, name)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			processed = append(processed, name)
+			return nil
+		})
+
+		var multi *MultiError
+		if assert.ErrorAs(err, &multi) {
+			assert.Equal([]string{"file0", "file1"}, multi.Paths())
+		}
+		assert.ErrorIs(err, errBoom)
+		assert.ElementsMatch([]string{"dir/", "file2"}, processed)
+	})
+
+	t.Run("with a corrupt entry", func(t *testing.T) {
+		corrupt := newArchive(map[string]string{"file0": "good", "file1": "bad", "file3": "good"})
+		// Change a byte of file1's stored content so that its checksum fails.
+		at := bytes.Index(corrupt, []byte("bad"))
+		corrupt[at] = 'B'
+
+		r := NewParallelReader()
+
+		var mu sync.Mutex
+		got := map[string]string{}
+		err := r.ReadZip(bytes.NewReader(corrupt), int64(len(corrupt)), func(name string, content []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got[name] += string(content)
+			return nil
+		})
+
+		var multi *MultiError
+		if assert.ErrorAs(err, &multi) {
+			assert.Equal([]string{"file1"}, multi.Paths())
+		}
+		assert.ErrorIs(err, zip.ErrChecksum)
+		assert.Equal("good", got["file0"])
+		assert.Equal("good", got["file3"])
+	})
+
+	t.Run("with Stop", func(t *testing.T) {
+		var many bytes.Buffer
+		zw := zip.NewWriter(&many)
+		for i := 0; i < 100; i++ {
+			w, _ := zw.Create(fmt.Sprintf("file%d", i))
+			binary.Write(w, binary.LittleEndian, int64(i))
+		}
+		zw.Close()
+
+		r := NewParallelReader()
+		r.Concurrency = 2
+
+		var calls int64
+		err := r.ReadZip(bytes.NewReader(many.Bytes()), int64(many.Len()), func(name string, content []byte) error {
+			atomic.AddInt64(&calls, 1)
+			return Stop
+		})
+
+		assert.NoError(err)
+		assert.Less(atomic.LoadInt64(&calls), int64(100))
+	})
+
+	t.Run("with an invalid archive", func(t *testing.T) {
+		r := NewParallelReader()
+
+		err := r.ReadZip(strings.NewReader("not a zip"), 9, func(name string, content []byte) error {
+			return nil
+		})
+
+		assert.ErrorIs(err, zip.ErrFormat)
+	})
+}